@@ -0,0 +1,174 @@
+package changelog
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Bump is the part of a semantic version which a set of changes calls to
+// increment.
+type Bump int
+
+const (
+	// BumpNone means there is nothing to release.
+	BumpNone Bump = iota
+	// BumpPatch is for backwards compatible bug fixes.
+	BumpPatch
+	// BumpMinor is for backwards compatible new functionality.
+	BumpMinor
+	// BumpMajor is for incompatible changes.
+	BumpMajor
+)
+
+// String returns the name of the bump, e.g. "minor".
+func (b Bump) String() string {
+	switch b {
+	case BumpPatch:
+		return "patch"
+	case BumpMinor:
+		return "minor"
+	case BumpMajor:
+		return "major"
+	}
+	return "none"
+}
+
+// BumpRules maps subsection names to the bump their changes call for. Names
+// are compared case-insensitively. Changes which aren't in a subsection, or
+// are in a subsection without a rule, call for a patch release.
+type BumpRules map[string]Bump
+
+// DefaultBumpRules covers the subsections of the Jekyll and Keep a
+// Changelog dialects.
+var DefaultBumpRules = BumpRules{
+	"Major Enhancements": BumpMajor,
+	"Minor Enhancements": BumpMinor,
+	"Bug Fixes":          BumpPatch,
+	Added:                BumpMinor,
+	Changed:              BumpMinor,
+	Deprecated:           BumpMinor,
+	Removed:              BumpMajor,
+	Fixed:                BumpPatch,
+	Security:             BumpPatch,
+}
+
+// breakingRegexp matches the marker for a change which breaks backwards
+// compatibility, e.g. "BREAKING: Drop support for Go 1.13".
+var breakingRegexp = regexp.MustCompile(`\bBREAKING\b`)
+
+// subsection returns the bump the changes in the named subsection call for.
+func (r BumpRules) subsection(name string) Bump {
+	if bump, ok := r[name]; ok {
+		return bump
+	}
+	for subsectionName, bump := range r {
+		if strings.EqualFold(subsectionName, name) {
+			return bump
+		}
+	}
+	return BumpPatch
+}
+
+// Bump returns the largest bump called for by the changes in the version.
+// A change marked "BREAKING" calls for a major release, wherever it is.
+func (r BumpRules) Bump(v *Version) Bump {
+	bump := BumpNone
+	raise := func(lines []*ChangeLine, lineBump Bump) {
+		for _, line := range lines {
+			if breakingRegexp.MatchString(line.Summary) {
+				bump = BumpMajor
+			} else if lineBump > bump {
+				bump = lineBump
+			}
+		}
+	}
+	raise(v.History, BumpPatch)
+	for _, subsection := range v.Subsections {
+		raise(subsection.History, r.subsection(subsection.Name))
+	}
+	return bump
+}
+
+// LatestVersion fetches the release with the highest semantic version.
+// Returns nil if there are no releases with a semantic version.
+func (c *Changelog) LatestVersion() *Version {
+	var latest *Version
+	for _, v := range c.Versions {
+		if _, ok := parseSemver(v.Version); !ok || versionRank(v.Version) != 0 {
+			continue
+		}
+		if latest == nil || v.Compare(latest) > 0 {
+			latest = v
+		}
+	}
+	return latest
+}
+
+// NextVersion suggests the version to release the unreleased changes as,
+// using DefaultBumpRules. See NextVersionWithRules.
+func (c *Changelog) NextVersion() (string, error) {
+	return c.NextVersionWithRules(DefaultBumpRules)
+}
+
+// NextVersionWithRules suggests the version to release the unreleased
+// changes as, by bumping the latest release according to the given rules.
+// A changelog without releases starts from 0.0.0. The version is written
+// the way the latest release is, e.g. with a "v" prefix.
+func (c *Changelog) NextVersionWithRules(rules BumpRules) (string, error) {
+	unreleased := c.GetUnreleased()
+	if unreleased == nil {
+		return "", fmt.Errorf("there are no unreleased changes")
+	}
+	bump := rules.Bump(unreleased)
+	if bump == BumpNone {
+		return "", fmt.Errorf("there are no unreleased changes")
+	}
+
+	latest, prefix := &semver{}, ""
+	if version := c.LatestVersion(); version != nil {
+		latest, _ = parseSemver(version.Version)
+		if strings.HasPrefix(strings.Trim(version.Version, "[]"), "v") {
+			prefix = "v"
+		}
+	}
+	return prefix + latest.bump(bump).String(), nil
+}
+
+// bump returns the next version after this one for the given bump. A
+// pre-release is followed by its release if that is enough of a bump, e.g.
+// a minor bump turns "1.2.0-rc.1" into "1.2.0".
+func (v *semver) bump(bump Bump) *semver {
+	next := &semver{major: v.major, minor: v.minor, patch: v.patch}
+	prerelease := len(v.prerelease) > 0
+	switch bump {
+	case BumpMajor:
+		if !prerelease || v.minor != 0 || v.patch != 0 {
+			next.major++
+		}
+		next.minor, next.patch = 0, 0
+	case BumpMinor:
+		if !prerelease || v.patch != 0 {
+			next.minor++
+		}
+		next.patch = 0
+	case BumpPatch:
+		if !prerelease {
+			next.patch++
+		}
+	}
+	return next
+}
+
+// String returns the version number, e.g. "1.2.3-rc.1+build.5".
+func (v *semver) String() string {
+	str := strconv.Itoa(v.major) + "." + strconv.Itoa(v.minor) + "." + strconv.Itoa(v.patch)
+	if len(v.prerelease) > 0 {
+		str += "-" + strings.Join(v.prerelease, ".")
+	}
+	if v.build != "" {
+		str += "+" + v.build
+	}
+	return str
+}
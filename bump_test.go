@@ -0,0 +1,117 @@
+package changelog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBumpRules_Bump(t *testing.T) {
+	testCases := []struct {
+		subsection string
+		summary    string
+		expected   Bump
+	}{
+		{"Major Enhancements", "Drop support for Ruby 1.9", BumpMajor},
+		{"Minor Enhancements", "Add a --livereload flag", BumpMinor},
+		{"Bug Fixes", "Fix the tokenizer", BumpPatch},
+		{"bug fixes", "Fix the tokenizer", BumpPatch},
+		{Added, "v1.1 German Translation", BumpMinor},
+		{Removed, "Unused normalize.css file", BumpMajor},
+		{Fixed, "Improve French translation", BumpPatch},
+		{Security, "Escape the titles", BumpPatch},
+		{"Development Fixes", "Update the test suite", BumpPatch},
+		{"Bug Fixes", "BREAKING: Fix the default permalink", BumpMajor},
+		{"Bug Fixes", "Fix BREAKINGNEWS parsing", BumpPatch},
+	}
+	for _, testCase := range testCases {
+		version := NewVersion("HEAD")
+		version.Subsections = []*Subsection{{
+			Name:    testCase.subsection,
+			History: []*ChangeLine{{Summary: testCase.summary}},
+		}}
+		assert.Equal(t, testCase.expected, DefaultBumpRules.Bump(version), "%s: %s", testCase.subsection, testCase.summary)
+	}
+
+	empty := NewVersion("[Unreleased]")
+	empty.Subsections = []*Subsection{NewSubsection(Removed)}
+	assert.Equal(t, BumpNone, DefaultBumpRules.Bump(empty))
+
+	direct := NewVersion("HEAD")
+	direct.History = []*ChangeLine{{Summary: "Fix the tokenizer"}}
+	assert.Equal(t, BumpPatch, DefaultBumpRules.Bump(direct))
+}
+
+func TestBumpString(t *testing.T) {
+	assert.Equal(t, "none", BumpNone.String())
+	assert.Equal(t, "patch", BumpPatch.String())
+	assert.Equal(t, "minor", BumpMinor.String())
+	assert.Equal(t, "major", BumpMajor.String())
+}
+
+func TestLatestVersion(t *testing.T) {
+	history := NewChangelog()
+	assert.Nil(t, history.LatestVersion())
+
+	history.GetVersionOrCreate("HEAD")
+	history.getVersionOrAppend("1.9.0")
+	history.getVersionOrAppend("2015-02-20")
+	history.getVersionOrAppend("1.10.0")
+	assert.Equal(t, "1.10.0", history.LatestVersion().Version)
+}
+
+func TestNextVersion(t *testing.T) {
+	history, err := NewChangelogFromFile("testdata/History.markdown")
+	assert.NoError(t, err)
+	next, err := history.NextVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, "3.0.0", next)
+
+	history = parseKeepAChangelog(t)
+	next, err = history.NextVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, "2.0.0", next)
+
+	next, err = history.NextVersionWithRules(BumpRules{Removed: BumpMinor, Added: BumpMinor})
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.0", next)
+}
+
+func TestNextVersion_Prefixes(t *testing.T) {
+	testCases := []struct {
+		latest   string
+		line     string
+		expected string
+	}{
+		{"", "  * Fix the tokenizer", "0.0.1"},
+		{"## v1.2.3", "  * Fix the tokenizer", "v1.2.4"},
+		{"## [v1.2.3] - 2026-01-02", "- Fix the tokenizer", "v1.2.4"},
+		{"## 2.0.0-rc.1", "  * Fix the tokenizer", "2.0.0"},
+		{"## 2.0.0-rc.1", "  * BREAKING: Drop the tokenizer", "2.0.0"},
+		{"## 2.0.1-rc.1", "  * BREAKING: Drop the tokenizer", "3.0.0"},
+		{"## 1.2.0-beta+build.5", "  * Fix the tokenizer", "1.2.0"},
+	}
+	for _, testCase := range testCases {
+		source := "## HEAD\n\n" + testCase.line + "\n"
+		if testCase.latest != "" {
+			source += "\n" + testCase.latest + "\n\n  * Initial release\n"
+		}
+		history, err := NewChangelogFromReader(strings.NewReader(source))
+		assert.NoError(t, err)
+		next, err := history.NextVersion()
+		assert.NoError(t, err)
+		assert.Equal(t, testCase.expected, next, "after %q", testCase.latest)
+	}
+}
+
+func TestNextVersion_NoChanges(t *testing.T) {
+	history := NewChangelog()
+	history.GetVersionOrCreate("1.0.0")
+	_, err := history.NextVersion()
+	assert.EqualError(t, err, "there are no unreleased changes")
+
+	history.GetVersionOrCreate("HEAD")
+	_, err = history.NextVersion()
+	assert.EqualError(t, err, "there are no unreleased changes")
+}
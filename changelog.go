@@ -2,7 +2,9 @@ package changelog
 
 import (
 	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -10,17 +12,106 @@ import (
 // versions that are tracked in the changelog. For supported formats, see
 // the documentation for Version.
 type Changelog struct {
+	// Preamble is the markdown which comes before the first version, such
+	// as a title and an introductory paragraph.
+	Preamble string
 	Versions []*Version
+	// Epilogue is the markdown which comes after the last change, such as
+	// link reference definitions.
+	Epilogue string
+
+	// Dialect is used to write the changelog. It is detected when parsing,
+	// and can be changed with SetDialect to convert the changelog to
+	// another dialect. If nil, Jekyll is used.
+	Dialect Dialect
+	// Style overrides the formatting details the Dialect leaves open, like
+	// the list marker used for changes. It is detected when parsing, so
+	// that the changelog is written back the way it was read. If nil, the
+	// Dialect's default style is used, and any unset fields are taken from
+	// it.
+	Style *Style
+
+	// Diagnostics holds the problems the parser was able to work around
+	// while reading this changelog, such as text it had to drop. It is
+	// empty for changelogs which weren't parsed.
+	Diagnostics []*ParseError
+
+	// epilogueSeparator is the separator the epilogue was written after,
+	// if it isn't a single blank line.
+	epilogueSeparator string
+}
+
+// block is a part of the markdown of a changelog, like a header or a list
+// of changes, along with the separator which was written before it when
+// the changelog was parsed. An empty separator stands for the usual one.
+type block struct {
+	separator string
+	text      string
+}
+
+// joinBlocks joins the blocks which aren't empty, separating them with a
+// blank line unless they have a separator of their own.
+func joinBlocks(blocks []block) string {
+	var b strings.Builder
+	for _, block := range blocks {
+		if block.text == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			if block.separator == "" {
+				block.separator = "\n\n"
+			}
+			b.WriteString(block.separator)
+		}
+		b.WriteString(block.text)
+	}
+	return b.String()
 }
 
 // A Markdown string representation of the Changelog.
 func (c *Changelog) String() string {
 	c.sortVersions()
-	versionStrs := make([]string, len(c.Versions))
-	for i, version := range c.Versions {
-		versionStrs[i] = version.String()
+	c.updateLinks()
+	blocks := []block{{text: c.Preamble}}
+	for _, version := range c.Versions {
+		blocks = append(blocks, version.blocks(c.dialect(), c.style())...)
+	}
+	// The link reference definitions take the place of the epilogue if
+	// there is none.
+	links := block{text: c.links()}
+	if c.Epilogue == "" {
+		links.separator = c.epilogueSeparator
+	}
+	blocks = append(blocks, block{c.epilogueSeparator, c.Epilogue}, links)
+	str := joinBlocks(blocks) + "\n"
+	if c.style().LineEnding == "\r\n" {
+		str = strings.Replace(str, "\n", "\r\n", -1)
+	}
+	return str
+}
+
+// dialect returns the Dialect used to write the changelog.
+func (c *Changelog) dialect() Dialect {
+	if c.Dialect == nil {
+		return Jekyll
 	}
-	return strings.Join(versionStrs, "\n\n") + "\n"
+	return c.Dialect
+}
+
+// style returns the Style used to write the changelog.
+func (c *Changelog) style() *Style {
+	style := c.dialect().DefaultStyle()
+	if c.Style != nil {
+		style = c.Style.withDefaults(style)
+	}
+	return &style
+}
+
+// SetDialect converts the changelog to the given dialect. The style of the
+// changelog is reset to the dialect's default style.
+func (c *Changelog) SetDialect(d Dialect) {
+	c.Dialect = d
+	c.Style = nil
 }
 
 // Version contains the data for the changes for a given version. It can
@@ -29,48 +120,69 @@ func (c *Changelog) String() string {
 //
 //	## 2.4.1
 //	## 2.4.1 / 2015-04-23
+//	## [2.4.1] - 2015-04-23
+//	## [2.4.1] - 2015-04-23 [YANKED]
 //
-// The version currently cannot be prefixed with a `v`, but a date is
-// optional.
+// The date is optional.
 type Version struct {
-	Version     string
-	Date        string
+	Version string
+	Date    string
+	// Yanked is set for releases which were pulled because of a serious
+	// bug or security issue.
+	Yanked bool
+	// URL links to the release or to the changes it contains, e.g. a
+	// compare view between the previous version and this one.
+	URL string
+	// Description is any prose between the version header and its first
+	// change or subsection.
+	Description string
 	History     []*ChangeLine
 	Subsections []*Subsection
 
 	sortOrder int
+	// urlLabel is the label used for URL in the source, if it was parsed.
+	urlLabel string
+	// separator and descriptionSeparator are the separators the header
+	// and the Description were written after, if they aren't a single
+	// blank line.
+	separator, descriptionSeparator string
 }
 
-// String returns the markdown representation for the version.
+// String returns the markdown representation for the version in the
+// Jekyll dialect.
 func (v *Version) String() string {
-	lines := []string{}
-	if v.Version != "" {
-		if len(lines) == 0 {
-			lines = append(lines, "")
-		}
-		lines[0] += "## " + v.Version
-	}
-	if v.Date != "" {
-		if len(lines) == 0 {
-			lines = append(lines, "")
-		}
-		lines[0] += " / " + v.Date
-	}
-	if len(v.History) > 0 {
-		historyStrs := make([]string, len(v.History))
-		for i, history := range v.History {
-			historyStrs[i] = history.String()
-		}
-		lines = append(lines, strings.Join(historyStrs, "\n"))
+	return v.Format(Jekyll)
+}
+
+// Format returns the markdown representation for the version in the given
+// dialect, using its default style.
+func (v *Version) Format(d Dialect) string {
+	style := d.DefaultStyle()
+	return v.format(d, &style)
+}
+
+func (v *Version) format(d Dialect, style *Style) string {
+	return joinBlocks(v.blocks(d, style))
+}
+
+// formatBody returns the markdown for everything under the version header.
+func (v *Version) formatBody(d Dialect, style *Style) string {
+	return joinBlocks(v.bodyBlocks(d, style))
+}
+
+func (v *Version) blocks(d Dialect, style *Style) []block {
+	return append([]block{{v.separator, d.VersionHeader(v, style)}}, v.bodyBlocks(d, style)...)
+}
+
+func (v *Version) bodyBlocks(d Dialect, style *Style) []block {
+	blocks := []block{
+		{v.descriptionSeparator, v.Description},
+		changeLinesBlock(v.History, d, style),
 	}
-	if len(v.Subsections) > 0 {
-		subsectionsStrs := make([]string, len(v.Subsections))
-		for i, subsection := range v.Subsections {
-			subsectionsStrs[i] = subsection.String()
-		}
-		lines = append(lines, strings.Join(subsectionsStrs, "\n\n"))
+	for _, subsection := range v.Subsections {
+		blocks = append(blocks, subsection.blocks(d, style)...)
 	}
-	return strings.Join(lines, "\n\n")
+	return blocks
 }
 
 // Subsection contains the data for a given subsection.
@@ -80,20 +192,62 @@ func (v *Version) String() string {
 //
 // Common subsections are "Major Enhancements," and "Bug Fixes."
 type Subsection struct {
-	Name    string
-	History []*ChangeLine
+	Name string
+	// Description is any prose between the subsection header and its
+	// first change.
+	Description string
+	History     []*ChangeLine
+
+	// separator and descriptionSeparator are the separators the header
+	// and the Description were written after, if they aren't a single
+	// blank line.
+	separator, descriptionSeparator string
 }
 
-// String returns the markdown representation of the subsection.
+// String returns the markdown representation of the subsection in the
+// Jekyll dialect.
 func (s *Subsection) String() string {
-	if len(s.History) > 0 {
-		historyStrs := make([]string, len(s.History))
-		for i, history := range s.History {
-			historyStrs[i] = history.String()
+	return s.Format(Jekyll)
+}
+
+// Format returns the markdown representation of the subsection in the
+// given dialect, using its default style.
+func (s *Subsection) Format(d Dialect) string {
+	style := d.DefaultStyle()
+	return s.format(d, &style)
+}
+
+func (s *Subsection) format(d Dialect, style *Style) string {
+	return joinBlocks(s.blocks(d, style))
+}
+
+func (s *Subsection) blocks(d Dialect, style *Style) []block {
+	return []block{
+		{s.separator, d.SubsectionHeader(s, style)},
+		{s.descriptionSeparator, s.Description},
+		changeLinesBlock(s.History, d, style),
+	}
+}
+
+// changeLinesBlock returns the list of changes. The changes are written
+// on consecutive lines, unless they were separated by blank lines when
+// they were parsed.
+func changeLinesBlock(lines []*ChangeLine, d Dialect, style *Style) block {
+	if len(lines) == 0 {
+		return block{}
+	}
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			separator := line.separator
+			if separator == "" {
+				separator = "\n"
+			}
+			b.WriteString(separator)
 		}
-		return "### " + s.Name + "\n\n" + strings.Join(historyStrs, "\n")
+		b.WriteString(line.format(d, style))
 	}
-	return ""
+	return block{lines[0].separator, b.String()}
 }
 
 // ChangeLine contains the data for a single change.
@@ -101,31 +255,55 @@ func (s *Subsection) String() string {
 //
 //   - This is a change (#1234)
 //   - This is another change. (@parkr)
+//   - This change has several references (#1234, #1235, @parkr)
+//   - So does this one (#1234) (@parkr)
 //   - This is a change w/o a reference.
 //
-// The references must be encased in parentheses, and only one reference is
-// currently supported.
+// The references must be encased in parentheses at the end of the line.
 type ChangeLine struct {
 	// What the change entails.
 	Summary string
-	// Reference can be either a username (e.g. @parkr) or a PR number
-	// (e.g. #1234).
+	// Reference holds the references without the outer parentheses, e.g.
+	// "#1234", "@parkr" or "#1234, @parkr". References listed in several
+	// groups, like "(#1234) (@parkr)", are joined into one list, but are
+	// written back as they were as long as Reference isn't changed. Use
+	// References to get them individually.
 	Reference string
+
+	// referenceSource holds the references as written when they were
+	// listed in several groups, e.g. "#1234) (@parkr".
+	referenceSource string
+	// separator is the separator the change was written after, if it
+	// isn't the usual one: a line break between changes, or a blank line
+	// before the first change of a list.
+	separator string
 }
 
-// String returns the markdown representation of the ChangeLine.
+// String returns the markdown representation of the ChangeLine in the
+// Jekyll dialect.
 // E.g. "  * Added documentation. (#123)"
+//
+// If the summary spans several lines, the reference is written at the end
+// of the first one.
 func (l *ChangeLine) String() string {
-	str := "  * " + l.Summary
-	if l.Reference != "" {
-		str += " (" + l.Reference + ")"
-	}
-	return str
+	return l.Format(Jekyll)
+}
+
+// Format returns the markdown representation of the ChangeLine in the
+// given dialect, using its default style.
+func (l *ChangeLine) Format(d Dialect) string {
+	style := d.DefaultStyle()
+	return l.format(d, &style)
+}
+
+func (l *ChangeLine) format(d Dialect, style *Style) string {
+	return d.ChangeLine(l, style)
 }
 
-// NewChangelog creates a pristine Changelog.
+// NewChangelog creates a pristine Changelog in the Jekyll dialect.
 func NewChangelog() *Changelog {
-	return &Changelog{Versions: []*Version{}}
+	style := Jekyll.DefaultStyle()
+	return &Changelog{Versions: []*Version{}, Dialect: Jekyll, Style: &style}
 }
 
 // NewChangelog builds a changelog from the file at the provided filename.
@@ -148,3 +326,34 @@ func NewChangelogFromReader(reader io.Reader) (*Changelog, error) {
 	}
 	return history, nil
 }
+
+// WriteFile writes the changelog to the file at the provided filename. The
+// changelog is written to a temporary file in the same directory first,
+// which then replaces the file, so that the file is never left half
+// written. An existing file keeps its permissions.
+func (c *Changelog) WriteFile(filename string) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(filename); err == nil {
+		mode = info.Mode().Perm()
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	file, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString(c.String()); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Chmod(mode); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), filename)
+}
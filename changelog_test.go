@@ -1,7 +1,9 @@
 package changelog
 
 import (
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -69,8 +71,8 @@ func TestChangelogString_Complex(t *testing.T) {
 	expected, err := os.ReadFile("testdata/changelog-string-complex.md")
 	assert.NoError(t, err)
 	assertSortOrder(t, history, "", -1)
-	assertSortOrder(t, history, "1.2.3", 1)
-	assertSortOrder(t, history, "3.2.1", 2)
+	assertSortOrder(t, history, "3.2.1", 1)
+	assertSortOrder(t, history, "1.2.3", 2)
 	assert.Equal(t, string(expected), actual)
 }
 
@@ -96,9 +98,9 @@ func TestChangelog_ParsesWhatItWrites(t *testing.T) {
 
 	assertSortOrder(t, history, "", -1)
 	assertSortOrder(t, history, "HEAD", 0)
-	assertSortOrder(t, history, "1.2.3", 1)
+	assertSortOrder(t, history, "5.4.1", 1)
 	assertSortOrder(t, history, "3.2.1", 2)
-	assertSortOrder(t, history, "5.4.1", 3)
+	assertSortOrder(t, history, "1.2.3", 3)
 	assert.Equal(t, history, actual, "expected:\n%q\nactual:\n%q\n", history.String(), actual.String())
 }
 
@@ -116,3 +118,107 @@ func TestChangelog_WritesWhatItParses(t *testing.T) {
 	wfd, _ := os.Create("testdata/History-rewritten.md")
 	wfd.WriteString(actual)
 }
+
+func TestChangelog_WritesWhatItParses_Prose(t *testing.T) {
+	expected, err := os.ReadFile("testdata/changelog-prose.md")
+	assert.NoError(t, err)
+
+	history, err := NewChangelogFromReader(strings.NewReader(string(expected)))
+	assert.NoError(t, err)
+
+	assert.Equal(t, string(expected), history.String())
+}
+
+func TestChangeLineString_MultipleLines(t *testing.T) {
+	line := &ChangeLine{Summary: "Maintain sort order\n\n  More details.", Reference: "#21"}
+	assert.Equal(t, "  * Maintain sort order (#21)\n\n  More details.", line.String())
+}
+
+func TestSubsectionString_Empty(t *testing.T) {
+	assert.Equal(t, "### Bug Fixes", NewSubsection("Bug Fixes").String())
+}
+
+func TestChangelogWriteFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "changelog")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "History.markdown")
+
+	history := NewChangelog()
+	history.AddLineToSubsection("HEAD", "Bug Fixes", &ChangeLine{Summary: "Fix the tokenizer", Reference: "#123"})
+	assert.NoError(t, history.WriteFile(filename))
+	contents, err := ioutil.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, history.String(), string(contents))
+
+	assert.NoError(t, os.Chmod(filename, 0600))
+	history.AddLineToVersion("1.0.0", &ChangeLine{Summary: "Initial release"})
+	assert.NoError(t, history.WriteFile(filename))
+	contents, err = ioutil.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, history.String(), string(contents))
+
+	info, err := os.Stat(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	infos, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, infos, 1, "the temporary file should be gone")
+}
+
+func TestChangelog_WritesWhatItParses_NestedLists(t *testing.T) {
+	expected, err := os.ReadFile("testdata/changelog-nested-lists.md")
+	assert.NoError(t, err)
+
+	history, err := NewChangelogFromReader(strings.NewReader(string(expected)))
+	assert.NoError(t, err)
+
+	assert.Equal(t, string(expected), history.String())
+	changes := history.GetSubsection("HEAD", "Minor Enhancements").History
+	assert.Len(t, changes, 2)
+	assert.Equal(t, "Add a `between` command\n    * Prints the changes of every version in the range\n    * Removes duplicate changes", changes[0].Summary)
+	assert.Equal(t, "#40", changes[0].Reference)
+	assert.Len(t, history.GetVersion("1.0.0").History, 2)
+}
+
+func TestChangelog_WritesWhatItParses_Layout(t *testing.T) {
+	for _, filename := range []string{
+		"testdata/changelog-crlf.md",
+		"testdata/keep-a-changelog-compact.md",
+		"testdata/changelog-blank-lines.md",
+	} {
+		expected, err := os.ReadFile(filename)
+		assert.NoError(t, err)
+
+		history, err := NewChangelogFromReader(strings.NewReader(string(expected)))
+		assert.NoError(t, err)
+
+		assert.Equal(t, string(expected), history.String(), filename)
+	}
+}
+
+func TestChangelog_Layout_NewParts(t *testing.T) {
+	history, err := NewChangelogFromFile("testdata/keep-a-changelog-compact.md")
+	assert.NoError(t, err)
+
+	history.AddLineToSubsection("[Unreleased]", Fixed, &ChangeLine{Summary: "Fix the parser"})
+	history.AddLineToSubsection("[Unreleased]", Security, &ChangeLine{Summary: "Escape the output"})
+
+	assert.Contains(t, history.String(), `### Fixed
+- Keep the layout of compact changelogs
+- Fix the parser
+
+### Security
+
+- Escape the output
+
+## [1.0.0] - 2017-06-20
+### Added
+`)
+
+	history, err = NewChangelogFromFile("testdata/changelog-crlf.md")
+	assert.NoError(t, err)
+	assert.Equal(t, "\r\n", history.Style.LineEnding)
+	history.AddLineToVersion("HEAD", &ChangeLine{Summary: "Add a line"})
+	assert.NotContains(t, strings.Replace(history.String(), "\r\n", "", -1), "\n")
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/parkr/changelog"
+)
+
+var addCommand = &command{
+	name:    "add",
+	usage:   "SUMMARY",
+	summary: "Add a change to the changelog.",
+	run:     runAdd,
+}
+
+func runAdd(flags *flag.FlagSet, args []string) error {
+	filename := fileFlags(flags)
+	versionNum := flags.String("version", "", "The version to add the change to (default: the unreleased changes)")
+	section := flags.String("section", "", "The subsection to add the change to, e.g. \"Bug Fixes\" (default: none)")
+	reference := flags.String("ref", "", "The reference for the change, e.g. \"#123\" or \"@parkr\"")
+	if err := parseFlags(flags, args, 1, -1); err != nil {
+		return err
+	}
+	summary := strings.TrimSpace(strings.Join(flags.Args(), " "))
+	if summary == "" {
+		return usageError{"the summary can't be empty"}
+	}
+
+	history, err := readChangelog(*filename)
+	if err != nil {
+		return err
+	}
+
+	// "HEAD" and "[Unreleased]" both mean the unreleased changes, whatever
+	// the dialect calls them.
+	if *versionNum == "" || strings.EqualFold(*versionNum, "HEAD") || strings.EqualFold(*versionNum, "[Unreleased]") {
+		if unreleased := history.GetUnreleased(); unreleased != nil {
+			*versionNum = unreleased.Version
+		} else {
+			*versionNum = history.Dialect.Unreleased()
+		}
+	}
+
+	line := &changelog.ChangeLine{Summary: summary, Reference: *reference}
+	if history.HasLine(*versionNum, *section, line) {
+		return fmt.Errorf("%s already lists this change", *versionNum)
+	}
+	if *section == "" {
+		history.AddLineToVersion(*versionNum, line)
+	} else {
+		history.AddLineToSubsection(*versionNum, *section, line)
+	}
+	return writeChangelog(history, *filename)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/parkr/changelog"
+)
+
+var betweenCommand = &command{
+	name:    "between",
+	usage:   "FROM [TO]",
+	summary: "Print the changes made after FROM up to TO, combined into one list.",
+	help: `The changes of all the versions in between are grouped by subsection, and
+changes listed in several versions are only printed once, e.g. for the notes
+of an upgrade from 3.8.2 to 4.1.0:
+
+	changelogger between 3.8.2 4.1.0
+
+Without TO, the changes up to and including the unreleased ones are printed.`,
+	run: runBetween,
+}
+
+func runBetween(flags *flag.FlagSet, args []string) error {
+	filename := fileFlags(flags)
+	noHeader := flags.Bool("no-header", false, "Leave out the version header")
+	output := flags.String("o", "markdown", "The output format: markdown, text or json")
+	repo := flags.String("repo", "", "The URL of the repository to link references like #123 to, e.g. https://github.com/parkr/changelog")
+	forgeName := flags.String("forge", "", "The forge hosting -repo: github, gitlab, gitea or bitbucket (default: detected from the URL)")
+	if err := parseFlags(flags, args, 1, 2); err != nil {
+		return err
+	}
+	switch *output {
+	case "markdown", "text", "json":
+	default:
+		return usageError{fmt.Sprintf("unknown output format %q", *output)}
+	}
+	from, to := flags.Arg(0), flags.Arg(1)
+
+	history, err := readChangelog(*filename)
+	if err != nil {
+		return err
+	}
+	if to == "" {
+		to = "HEAD"
+	}
+	versions, err := history.Between(from, to)
+	if err != nil {
+		return usageError{err.Error()}
+	}
+	if len(versions) == 0 {
+		return fmt.Errorf("no versions after %s up to %s in %s", from, to, displayName(*filename))
+	}
+
+	numbers := make([]string, len(versions))
+	for i, v := range versions {
+		numbers[i] = v.Version
+	}
+	version := changelog.CombineVersions(from+"..."+to, versions)
+	version.Description = "Includes " + strings.Join(numbers, ", ") + "."
+	if *repo != "" {
+		resolver, err := newResolver(*repo, *forgeName)
+		if err != nil {
+			return usageError{err.Error()}
+		}
+		version = version.Linkify(resolver, *output == "markdown")
+	}
+
+	switch *output {
+	case "text":
+		fmt.Println(version.Text(!*noHeader))
+	case "json":
+		return writeJSON(version, "-")
+	default:
+		fmt.Println(history.FormatVersion(version, !*noHeader))
+	}
+	return nil
+}
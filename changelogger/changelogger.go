@@ -1,50 +1,253 @@
+// changelogger reads, writes and edits markdown changelogs.
+//
+//	changelogger <command> [flags] [arguments]
+//
+// Run "changelogger help" for the list of commands, and
+// "changelogger <command> -h" for the flags of a command.
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"flag"
+	"fmt"
 	"io"
-	"log"
+	"io/ioutil"
 	"os"
+	"strings"
 
 	"github.com/parkr/changelog"
 )
 
+// command is a subcommand of changelogger.
+type command struct {
+	name string
+	// usage describes the arguments of the command, after its flags.
+	usage string
+	// summary is a one-line description of the command.
+	summary string
+	// help describes the command further in its usage, if needed.
+	help string
+	// run runs the command with the flags and arguments which follow its
+	// name.
+	run func(flags *flag.FlagSet, args []string) error
+}
+
+var commands = []*command{
+	fmtCommand,
+	showCommand,
+	convertCommand,
+	addCommand,
+	releaseCommand,
+	nextVersionCommand,
+	lintCommand,
+	fromGitCommand,
+	collectCommand,
+	newFragmentCommand,
+	feedCommand,
+	mergeDriverCommand,
+	diffCommand,
+	checkEntryCommand,
+	betweenCommand,
+	grepCommand,
+}
+
+// usageError is returned by a command when it is called the wrong way.
+type usageError struct {
+	message string
+}
+
+func (e usageError) Error() string {
+	return e.message
+}
+
+// errInvalidFlags is returned when the flags of a command can't be parsed.
+// The flag package has already reported the problem.
+var errInvalidFlags = errors.New("invalid flags")
+
+// errFailed is returned by a command which has already reported why it
+// failed, like fmt -check.
+var errFailed = errors.New("failed")
+
 func main() {
-	// Read options
-	var filename string
-	flag.StringVar(&filename, "file", "", "The path to your changelog")
-	var output string
-	flag.StringVar(&output, "out", "", "Where to write the changelog")
-	var verbose bool
-	flag.BoolVar(&verbose, "v", false, "Whether to print verbose output")
-	flag.Parse()
+	os.Exit(run(os.Args[1:]))
+}
 
-	changelog.SetVerbose(verbose)
+// run runs changelogger with the given arguments, and returns the exit
+// code: 0 on success, 1 on failure and 2 when called the wrong way.
+func run(args []string) int {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") && !isHelp(args[0]) {
+		// Before there were subcommands, changelogger only formatted the
+		// changelog.
+		args = append([]string{"fmt"}, args...)
+	}
+	if isHelp(args[0]) || args[0] == "help" {
+		if len(args) > 1 {
+			args = []string{args[1], "-h"}
+		} else {
+			printUsage(os.Stdout)
+			return 0
+		}
+	}
 
-	// Find History.markdown
-	if filename == "" {
-		filename = changelog.HistoryFilename()
+	cmd := findCommand(args[0])
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "changelogger: unknown command %q\n\n", args[0])
+		printUsage(os.Stderr)
+		return 2
+	}
+	flags := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s\n\n%s\n\n", strings.TrimSpace("changelogger "+cmd.name+" [flags] "+cmd.usage), cmd.summary)
+		if cmd.help != "" {
+			fmt.Fprintf(flags.Output(), "%s\n\n", cmd.help)
+		}
+		fmt.Fprintf(flags.Output(), "Flags:\n")
+		flags.PrintDefaults()
 	}
 
-	// Read History.markdown
-	history, err := changelog.NewChangelogFromFile(filename)
-	if err != nil {
-		log.Fatal(err)
+	err := cmd.run(flags, args[1:])
+	var usageErr usageError
+	switch {
+	case err == nil:
+		return 0
+	case err == flag.ErrHelp:
+		return 0
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(flags.Output(), "changelogger %s: %s\n", cmd.name, usageErr.message)
+		flags.Usage()
+		return 2
+	case err == errInvalidFlags:
+		return 2
+	case err == errFailed:
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "changelogger %s: %s\n", cmd.name, err)
+	return 1
+}
+
+func isHelp(arg string) bool {
+	switch arg {
+	case "-h", "-help", "--help":
+		return true
 	}
+	return false
+}
 
-	// Write History.markdown
-	var writer io.Writer
-	if output == "" {
-		writer = os.Stderr
-	} else {
-		f, err := os.Create(output)
-		if err != nil {
-			log.Fatal(err)
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
 		}
-		writer = f
-		defer f.Close()
 	}
-	if _, err := writer.Write([]byte(history.String())); err != nil {
-		log.Fatal(err)
+	return nil
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: changelogger <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-14s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "changelogger <command> -h" for the flags of a command.`)
+	fmt.Fprintln(w, `The changelog is read from -file, or found in the current directory.`)
+	fmt.Fprintln(w, `Use "-" as a filename to read from stdin or write to stdout.`)
+}
+
+// fileFlags adds the flags every command which reads a changelog has.
+func fileFlags(flags *flag.FlagSet) *string {
+	filename := flags.String("file", "", "The path to your changelog, or - for stdin (default: History.markdown or CHANGELOG.md in the current directory)")
+	flags.Bool("v", false, "Whether to print verbose output")
+	return filename
+}
+
+// parseFlags parses the flags of a command. The command takes between
+// minArgs and maxArgs arguments; maxArgs < 0 means there is no maximum.
+func parseFlags(flags *flag.FlagSet, args []string, minArgs, maxArgs int) error {
+	if err := flags.Parse(args); err == flag.ErrHelp {
+		return err
+	} else if err != nil {
+		return errInvalidFlags
+	}
+	if verbose := flags.Lookup("v"); verbose != nil && verbose.Value.String() == "true" {
+		changelog.SetVerbose(true)
+	}
+	switch {
+	case flags.NArg() < minArgs:
+		return usageError{"not enough arguments"}
+	case maxArgs >= 0 && flags.NArg() > maxArgs:
+		return usageError{"too many arguments"}
+	}
+	return nil
+}
+
+// readChangelog parses the changelog in the file, or in stdin if filename
+// is "-". An empty filename means the changelog in the current directory.
+func readChangelog(filename string) (*changelog.Changelog, error) {
+	switch filename {
+	case "":
+		return changelog.NewChangelogFromFile(changelog.HistoryFilename())
+	case "-":
+		return changelog.NewChangelogFromReader(os.Stdin)
+	}
+	return changelog.NewChangelogFromFile(filename)
+}
+
+// writeChangelog writes the changelog to the file, or to stdout if
+// filename is "-". An empty filename means the changelog in the current
+// directory.
+func writeChangelog(history *changelog.Changelog, filename string) error {
+	switch filename {
+	case "":
+		return history.WriteFile(changelog.HistoryFilename())
+	case "-":
+		_, err := io.WriteString(os.Stdout, history.String())
+		return err
+	}
+	return history.WriteFile(filename)
+}
+
+// writeJSON writes the JSON encoding of the value to the file, or to
+// stdout if filename is "-".
+func writeJSON(value interface{}, filename string) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeOutput(append(data, '\n'), filename)
+}
+
+// writeOutput writes the data to the file, or to stdout if filename is
+// "-".
+func writeOutput(data []byte, filename string) error {
+	if filename == "-" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0644)
+}
+
+// readSource reads the contents of the file, or of stdin if filename is
+// "-". An empty filename means the changelog in the current directory.
+func readSource(filename string) ([]byte, error) {
+	switch filename {
+	case "":
+		return ioutil.ReadFile(changelog.HistoryFilename())
+	case "-":
+		return ioutil.ReadAll(os.Stdin)
+	}
+	return ioutil.ReadFile(filename)
+}
+
+// displayName returns the name of the file to show in messages.
+func displayName(filename string) string {
+	switch filename {
+	case "":
+		return changelog.HistoryFilename()
+	case "-":
+		return "<stdin>"
 	}
+	return filename
 }
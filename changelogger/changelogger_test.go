@@ -0,0 +1,107 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const unformatted = `## 1.0.0
+
+  * Initial release (#1)
+  - Add a license (#2)
+`
+
+const formatted = `## 1.0.0
+
+  * Initial release (#1)
+  * Add a license (#2)
+`
+
+// runOutput runs changelogger with the given arguments, and returns its
+// exit code along with what it wrote to stdout and stderr.
+func runOutput(t *testing.T, args ...string) (int, string, string) {
+	stdout, err := ioutil.TempFile("", "changelogger-stdout")
+	assert.NoError(t, err)
+	defer os.Remove(stdout.Name())
+	stderr, err := ioutil.TempFile("", "changelogger-stderr")
+	assert.NoError(t, err)
+	defer os.Remove(stderr.Name())
+
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = stdout, stderr
+	code := run(args)
+	os.Stdout, os.Stderr = oldStdout, oldStderr
+	stdout.Close()
+	stderr.Close()
+
+	out, err := ioutil.ReadFile(stdout.Name())
+	assert.NoError(t, err)
+	errOut, err := ioutil.ReadFile(stderr.Name())
+	assert.NoError(t, err)
+	return code, string(out), string(errOut)
+}
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "changelogger")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	formattedFile := filepath.Join(dir, "formatted.md")
+	assert.NoError(t, ioutil.WriteFile(formattedFile, []byte(formatted), 0644))
+	unformattedFile := filepath.Join(dir, "unformatted.md")
+	assert.NoError(t, ioutil.WriteFile(unformattedFile, []byte(unformatted), 0644))
+	missingFile := filepath.Join(dir, "missing.md")
+
+	for _, testCase := range []struct {
+		name   string
+		args   []string
+		code   int
+		stdout string
+		stderr string
+	}{
+		// The dispatcher.
+		{name: "help", args: []string{"help"}, code: 0, stdout: "Usage: changelogger <command>"},
+		{name: "-h", args: []string{"-h"}, code: 0, stdout: "Commands:"},
+		{name: "help for a command", args: []string{"help", "fmt"}, code: 0, stderr: "Usage: changelogger fmt [flags]"},
+		{name: "-h for a command", args: []string{"between", "-h"}, code: 0, stderr: "Usage: changelogger between [flags] FROM [TO]"},
+		{name: "unknown command", args: []string{"frobnicate"}, code: 2, stderr: `unknown command "frobnicate"`},
+		{name: "command", args: []string{"fmt", "-file", unformattedFile}, code: 0, stdout: formatted},
+		{name: "failing command", args: []string{"fmt", "-file", missingFile}, code: 1, stderr: "changelogger fmt: "},
+
+		// Usage errors.
+		{name: "unknown flag", args: []string{"fmt", "-bogus"}, code: 2, stderr: "flag provided but not defined: -bogus"},
+		{name: "too many arguments", args: []string{"fmt", "extra"}, code: 2, stderr: "changelogger fmt: too many arguments"},
+		{name: "not enough arguments", args: []string{"between", "-file", formattedFile}, code: 2, stderr: "changelogger between: not enough arguments"},
+		{name: "conflicting flags", args: []string{"fmt", "-w", "-check", "-file", formattedFile}, code: 2, stderr: "-w and -check can't be used together"},
+		{name: "invalid argument", args: []string{"between", "-file", formattedFile, "latest"}, code: 2, stderr: `"latest" isn't a semantic version or HEAD`},
+		{name: "unknown dialect", args: []string{"convert", "-file", formattedFile, "rst"}, code: 2, stderr: "changelogger convert: "},
+
+		// fmt -check.
+		{name: "check formatted", args: []string{"fmt", "-check", "-file", formattedFile}, code: 0},
+		{name: "check unformatted", args: []string{"fmt", "-check", "-file", unformattedFile}, code: 1, stderr: unformattedFile + " is not formatted"},
+
+		// The flags of fmt, from before there were subcommands.
+		{name: "legacy check formatted", args: []string{"-file", formattedFile, "-check"}, code: 0},
+		{name: "legacy check unformatted", args: []string{"-check", "-file", unformattedFile}, code: 1, stderr: unformattedFile + " is not formatted"},
+		{name: "legacy format", args: []string{"-file", unformattedFile}, code: 0, stdout: formatted},
+		{name: "legacy usage error", args: []string{"-file", formattedFile, "-w", "-check"}, code: 2, stderr: "Usage: changelogger fmt [flags]"},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			code, stdout, stderr := runOutput(t, testCase.args...)
+
+			assert.Equal(t, testCase.code, code, "stderr: %s", stderr)
+			assert.Contains(t, stdout, testCase.stdout)
+			assert.Contains(t, stderr, testCase.stderr)
+			if testCase.code == 0 && testCase.stderr == "" {
+				assert.Empty(t, stderr)
+			}
+		})
+	}
+
+	contents, err := ioutil.ReadFile(unformattedFile)
+	assert.NoError(t, err)
+	assert.Equal(t, unformatted, string(contents), "fmt -check doesn't write")
+}
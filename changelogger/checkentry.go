@@ -0,0 +1,150 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/parkr/changelog"
+)
+
+var checkEntryCommand = &command{
+	name:    "check-entry",
+	summary: "Fail unless a change was added to or edited in the unreleased changes since a base revision, e.g. in CI.",
+	help: `The unreleased changes of the changelog in the working copy are compared with
+the ones at the commit where the current branch diverged from -base, and a
+change which was added or edited there passes the check. The check
+is skipped if the change has one of the -skip-label labels, adds the
+-skip-file, or only touches files matching -ignore. The -file and -skip-file
+paths are relative to the working directory, and the -ignore patterns to the
+root of the repository.`,
+	run: runCheckEntry,
+}
+
+// listFlag collects a flag which can be repeated.
+type listFlag []string
+
+func (f *listFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *listFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+func runCheckEntry(flags *flag.FlagSet, args []string) error {
+	filename := fileFlags(flags)
+	base := flags.String("base", "origin/main", "The git revision the change will be merged into")
+	labels := flags.String("labels", "", "The comma-separated labels of the change, e.g. of its pull request")
+	skipLabel := flags.String("skip-label", "no-changelog", "The label of changes which don't need a changelog entry")
+	skipFile := flags.String("skip-file", ".changelog-skip", "The file a change can add when it doesn't need a changelog entry")
+	ignore := &listFlag{}
+	flags.Var(ignore, "ignore", "Skip the check if every changed file matches this pattern, relative to the root of the repository, e.g. docs/ or *.md; can be repeated")
+	if err := parseFlags(flags, args, 0, 0); err != nil {
+		return err
+	}
+	if *filename == "-" {
+		return usageError{"check-entry needs the changelog in a file"}
+	}
+	if *filename == "" {
+		*filename = changelog.HistoryFilename()
+	}
+
+	for _, label := range strings.Split(*labels, ",") {
+		if label = strings.TrimSpace(label); label != "" && strings.EqualFold(label, *skipLabel) {
+			fmt.Printf("%s: skipped, the change is labeled %s\n", *filename, label)
+			return nil
+		}
+	}
+
+	mergeBase, err := changelog.FindGitMergeBase(".", *base)
+	if err != nil {
+		return err
+	}
+	changed, err := changelog.ReadGitChangedFiles(".", mergeBase)
+	if err != nil {
+		return err
+	}
+	root, err := changelog.FindGitRoot(".")
+	if err != nil {
+		return err
+	}
+	check := &changelog.EntryCheck{Root: root, Ignore: *ignore}
+	if check.Filename, err = repoPath(root, *filename); err != nil {
+		return err
+	}
+	if *skipFile != "" {
+		if check.SkipFile, err = repoPath(root, *skipFile); err != nil {
+			return err
+		}
+	}
+	if skip := check.SkipReason(changed); skip != "" {
+		fmt.Printf("%s: skipped, %s\n", *filename, skip)
+		return nil
+	}
+
+	old, err := changelog.ReadGitChangelog(root, mergeBase, check.Filename)
+	if errors.Is(err, os.ErrNotExist) {
+		old = changelog.NewChangelog()
+	} else if err != nil {
+		return err
+	}
+	history, err := readChangelog(*filename)
+	if err != nil {
+		return err
+	}
+
+	unreleased := history.Dialect.Unreleased()
+	// Rewording a change counts too, e.g. to mention a follow-up fix.
+	added := 0
+	for _, diff := range changelog.Diff(old, history).InVersion(unreleased) {
+		if (diff.Op == changelog.DiffAdded || diff.Op == changelog.DiffModified) && diff.New != nil {
+			added++
+		}
+	}
+	if added == 0 {
+		fmt.Fprintf(os.Stderr, `%s: no change was added or edited under "## %s" since %s.
+Describe the change there, e.g. with:
+
+	changelogger add -section SUBSECTION "What changed"
+
+If the change doesn't need an entry, label it %s or add %s.
+`, *filename, unreleased, *base, *skipLabel, *skipFile)
+		return errFailed
+	}
+	fmt.Printf("%s: %d %s added or edited under \"## %s\"\n", *filename, added, plural(added, "change", "changes"), unreleased)
+	return nil
+}
+
+// repoPath returns the slash-separated path of the file relative to the
+// root of the repository, given its path relative to the working directory.
+func repoPath(root, filename string) (string, error) {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return "", err
+	}
+	// The root has its symlinks resolved, e.g. /private/tmp for /tmp.
+	if dir, err := filepath.EvalSymlinks(filepath.Dir(abs)); err == nil {
+		abs = filepath.Join(dir, filepath.Base(abs))
+	}
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s isn't in the repository at %s", filename, root)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// plural returns singular if n is 1, and plural otherwise.
+func plural(n int, singular, plural string) string {
+	if n == 1 {
+		return singular
+	}
+	return plural
+}
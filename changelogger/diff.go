@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/parkr/changelog"
+)
+
+var diffCommand = &command{
+	name:    "diff",
+	usage:   "OLD NEW",
+	summary: "Print how the release notes changed between two changelogs.",
+	help: `Change lines are matched by their references and summaries, so that edited
+lines show up as changed rather than removed and added. Use -require to fail
+unless a version changed, e.g. to check that a pull request adds its changes
+to the unreleased ones:
+
+	changelogger diff -require HEAD <(git show origin/main:History.markdown) History.markdown`,
+	run: runDiff,
+}
+
+func runDiff(flags *flag.FlagSet, args []string) error {
+	output := flags.String("o", "human", "The output format: human, markdown or json")
+	require := flags.String("require", "", "Fail unless this version changed, e.g. HEAD for the unreleased changes")
+	flags.Bool("v", false, "Whether to print verbose output")
+	if err := parseFlags(flags, args, 2, 2); err != nil {
+		return err
+	}
+	switch *output {
+	case "human", "markdown", "json":
+	default:
+		return usageError{fmt.Sprintf("unknown output format %q", *output)}
+	}
+
+	old, err := readChangelog(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	updated, err := readChangelog(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	diffs := changelog.Diff(old, updated)
+
+	switch *output {
+	case "markdown":
+		fmt.Print(diffs.Markdown())
+	case "json":
+		if err := writeJSON(diffs, "-"); err != nil {
+			return err
+		}
+	default:
+		if len(diffs) > 0 {
+			fmt.Println(diffs)
+		}
+	}
+	if *require != "" && len(diffs.InVersion(*require)) == 0 {
+		fmt.Fprintf(os.Stderr, "%s: version %s didn't change\n", displayName(flags.Arg(1)), *require)
+		return errFailed
+	}
+	return nil
+}
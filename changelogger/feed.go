@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/parkr/changelog"
+)
+
+var feedCommand = &command{
+	name:    "feed",
+	summary: "Write an Atom or RSS feed of the releases, so users can subscribe to them.",
+	run:     runFeed,
+}
+
+func runFeed(flags *flag.FlagSet, args []string) error {
+	filename := fileFlags(flags)
+	out := flags.String("out", "-", "Where to write the feed, or - for stdout")
+	format := flags.String("format", "atom", "The format of the feed: atom or rss")
+	config := &changelog.FeedConfig{}
+	flags.StringVar(&config.Link, "link", "", "The URL of the page the changelog is published on (required)")
+	flags.StringVar(&config.Title, "title", "", "The title of the feed (default: the first heading of the changelog)")
+	flags.StringVar(&config.ID, "id", "", "The id of an Atom feed (default: -link)")
+	flags.StringVar(&config.Description, "description", "", "What the feed is about")
+	flags.StringVar(&config.Author, "author", "", "The author of an Atom feed (default: the title of the feed)")
+	flags.IntVar(&config.Limit, "limit", 20, "The maximum number of releases to list, or 0 for all of them")
+	repo := flags.String("repo", "", "The URL of the repository to link references like #123 to, e.g. https://github.com/parkr/changelog")
+	forgeName := flags.String("forge", "", "The forge hosting -repo: github, gitlab, gitea or bitbucket (default: detected from the URL)")
+	if err := parseFlags(flags, args, 0, 0); err != nil {
+		return err
+	}
+	if config.Link == "" {
+		return usageError{"feed needs a -link"}
+	}
+	config.Renderer = &changelog.HTMLRenderer{}
+	if *repo != "" {
+		resolver, err := newResolver(*repo, *forgeName)
+		if err != nil {
+			return usageError{err.Error()}
+		}
+		config.Renderer.Resolver = resolver
+	}
+
+	history, err := readChangelog(*filename)
+	if err != nil {
+		return err
+	}
+	var data []byte
+	switch *format {
+	case "atom":
+		data, err = history.Atom(config)
+	case "rss":
+		data, err = history.RSS(config)
+	default:
+		return usageError{fmt.Sprintf("unknown feed format %q", *format)}
+	}
+	if err != nil {
+		return err
+	}
+	return writeOutput(data, *out)
+}
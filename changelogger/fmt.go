@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"html/template"
+	"os"
+
+	"github.com/parkr/changelog"
+)
+
+var fmtCommand = &command{
+	name:    "fmt",
+	summary: "Format the changelog, or check that it is formatted.",
+	run:     runFmt,
+}
+
+var convertCommand = &command{
+	name:    "convert",
+	usage:   "[DIALECT]",
+	summary: "Convert the changelog to another dialect, to HTML, or to or from JSON.",
+	run:     runConvert,
+}
+
+// formatOptions are the flags shared by fmt and convert.
+type formatOptions struct {
+	filename *string
+	out      *string
+	write    *bool
+	check    *bool
+
+	// fromJSON is set by convert to read the JSON encoding of the
+	// changelog instead of markdown.
+	fromJSON bool
+	// to is set by convert to write the changelog as "json" or "html"
+	// instead of markdown.
+	to string
+	// html renders the changelog if to is "html", as a full page if page
+	// is set.
+	html *changelog.HTMLRenderer
+	page *template.Template
+}
+
+func formatFlags(flags *flag.FlagSet) *formatOptions {
+	return &formatOptions{
+		filename: fileFlags(flags),
+		out:      flags.String("out", "-", "Where to write the changelog, or - for stdout"),
+		write:    flags.Bool("w", false, "Write the changelog back to -file instead of -out"),
+		check:    flags.Bool("check", false, "Don't write anything; fail if the changelog would change"),
+	}
+}
+
+func runFmt(flags *flag.FlagSet, args []string) error {
+	options := formatFlags(flags)
+	dialectName := flags.String("format", "", "The dialect to write the changelog in (default: the dialect it was written in)")
+	if err := parseFlags(flags, args, 0, 0); err != nil {
+		return err
+	}
+	var dialect changelog.Dialect
+	if *dialectName != "" {
+		var err error
+		if dialect, err = changelog.DialectByName(*dialectName); err != nil {
+			return usageError{err.Error()}
+		}
+	}
+	return formatChangelog(options, dialect)
+}
+
+func runConvert(flags *flag.FlagSet, args []string) error {
+	options := formatFlags(flags)
+	from := flags.String("from", "markdown", "The format of the input: markdown or json")
+	to := flags.String("to", "", "The format to convert to: jekyll, keepachangelog, markdown (the dialect of the input), json or html")
+	page := flags.Bool("page", false, "With -to html, write a full HTML page instead of a fragment")
+	pageTemplate := flags.String("template", "", "With -to html, the html/template file to write the full page with; implies -page")
+	repo := flags.String("repo", "", "With -to html, the URL of the repository to link references like #123 to")
+	forgeName := flags.String("forge", "", "The forge hosting -repo: github, gitlab, gitea or bitbucket (default: detected from the URL)")
+	if err := parseFlags(flags, args, 0, 1); err != nil {
+		return err
+	}
+	target := *to
+	if flags.NArg() > 0 {
+		if target != "" {
+			return usageError{"the DIALECT argument and -to can't be used together"}
+		}
+		target = flags.Arg(0)
+	}
+	if target == "" {
+		if *from != "json" {
+			return usageError{"convert needs a DIALECT or -to"}
+		}
+		target = "markdown"
+	}
+
+	switch *from {
+	case "markdown":
+	case "json":
+		options.fromJSON = true
+	default:
+		return usageError{fmt.Sprintf("unknown input format %q", *from)}
+	}
+	switch target {
+	case "markdown":
+		return formatChangelog(options, nil)
+	case "json":
+		options.to = target
+		return formatChangelog(options, nil)
+	case "html":
+		options.to = target
+		options.html = &changelog.HTMLRenderer{}
+		if *repo != "" {
+			resolver, err := newResolver(*repo, *forgeName)
+			if err != nil {
+				return usageError{err.Error()}
+			}
+			options.html.Resolver = resolver
+		}
+		switch {
+		case *pageTemplate != "":
+			var err error
+			if options.page, err = template.ParseFiles(*pageTemplate); err != nil {
+				return err
+			}
+		case *page:
+			options.page = changelog.DefaultHTMLPageTemplate
+		}
+		return formatChangelog(options, nil)
+	}
+	if *page || *pageTemplate != "" || *repo != "" {
+		return usageError{"-page, -template and -repo can only be used with -to html"}
+	}
+	dialect, err := changelog.DialectByName(target)
+	if err != nil {
+		return usageError{err.Error()}
+	}
+	return formatChangelog(options, dialect)
+}
+
+// formatChangelog writes the changelog back out in the given dialect, or in its
+// own if dialect is nil.
+func formatChangelog(options *formatOptions, dialect changelog.Dialect) error {
+	if *options.write && *options.check {
+		return usageError{"-w and -check can't be used together"}
+	}
+	if *options.write && *options.filename == "-" {
+		return usageError{"-w can't write back to stdin"}
+	}
+
+	source, err := readSource(*options.filename)
+	if err != nil {
+		return err
+	}
+	var history *changelog.Changelog
+	if options.fromJSON {
+		history, err = changelog.NewChangelogFromJSON(bytes.NewReader(source))
+	} else {
+		history, err = changelog.NewChangelogFromReader(bytes.NewReader(source))
+	}
+	if err != nil {
+		return err
+	}
+	if dialect != nil {
+		history.SetDialect(dialect)
+	}
+	if options.to != "" || options.fromJSON {
+		if *options.write || *options.check {
+			return usageError{"-w and -check can only be used when converting markdown to markdown"}
+		}
+		switch options.to {
+		case "json":
+			return writeJSON(history, *options.out)
+		case "html":
+			return writeHTML(history, options.html, options.page, *options.out)
+		}
+		return writeChangelog(history, *options.out)
+	}
+
+	switch {
+	case *options.check:
+		if history.String() != string(source) {
+			fmt.Fprintf(os.Stderr, "%s is not formatted\n", displayName(*options.filename))
+			return errFailed
+		}
+		return nil
+	case *options.write:
+		if history.String() == string(source) {
+			return nil
+		}
+		return writeChangelog(history, *options.filename)
+	}
+	return writeChangelog(history, *options.out)
+}
+
+// writeHTML writes the changelog as HTML to the file, or to stdout if
+// filename is "-". If page is set, it is a full page written with it.
+func writeHTML(history *changelog.Changelog, renderer *changelog.HTMLRenderer, page *template.Template, filename string) error {
+	if page == nil {
+		return writeOutput([]byte(renderer.Render(history)), filename)
+	}
+	var buf bytes.Buffer
+	if err := renderer.RenderPage(&buf, history, page); err != nil {
+		return err
+	}
+	return writeOutput(buf.Bytes(), filename)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/parkr/changelog"
+)
+
+var collectCommand = &command{
+	name:    "collect",
+	summary: "Add the changes in the fragments directory to the unreleased changes, and delete the fragments.",
+	run:     runCollect,
+}
+
+var newFragmentCommand = &command{
+	name:    "new-fragment",
+	usage:   "[SUMMARY]",
+	summary: "Create a fragment for a change, e.g. changes/1234.bugfix.md.",
+	run:     runNewFragment,
+}
+
+// fragmentTypes returns the fragment types for the changelog's dialect.
+// A changelog which doesn't exist yet uses the Jekyll dialect.
+func fragmentTypes(filename string) (map[string]string, error) {
+	history, err := readChangelog(filename)
+	if os.IsNotExist(err) {
+		return changelog.FragmentTypes(changelog.Jekyll), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return changelog.FragmentTypes(history.Dialect), nil
+}
+
+func runCollect(flags *flag.FlagSet, args []string) error {
+	filename := fileFlags(flags)
+	dir := flags.String("dir", "changes", "The directory holding the fragments")
+	dryRun := flags.Bool("n", false, "Print the changes instead of adding them, and keep the fragments")
+	keep := flags.Bool("keep", false, "Keep the fragments after adding their changes")
+	if err := parseFlags(flags, args, 0, 0); err != nil {
+		return err
+	}
+
+	history, err := readChangelog(*filename)
+	if err != nil {
+		return err
+	}
+	fragments, err := changelog.ReadFragments(*dir, changelog.FragmentTypes(history.Dialect))
+	if err != nil {
+		return err
+	}
+	added := history.CollectFragments(fragments, nil)
+	if *dryRun {
+		for _, line := range added {
+			fmt.Println(line.Format(history.Dialect))
+		}
+		return nil
+	}
+	if len(added) > 0 {
+		if err := writeChangelog(history, *filename); err != nil {
+			return err
+		}
+	}
+	if *keep {
+		return nil
+	}
+	for _, fragment := range fragments {
+		if err := os.Remove(fragment.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runNewFragment(flags *flag.FlagSet, args []string) error {
+	filename := fileFlags(flags)
+	dir := flags.String("dir", "changes", "The directory holding the fragments")
+	reference := flags.String("ref", "", "The issue or pull request the change is for, e.g. 1234 (default: none)")
+	fragmentType := flags.String("type", "", "The type of the change, which decides its subsection, e.g. bugfix")
+	if err := parseFlags(flags, args, 0, -1); err != nil {
+		return err
+	}
+
+	types, err := fragmentTypes(*filename)
+	if err != nil {
+		return err
+	}
+	if *fragmentType == "" {
+		return usageError{"-type is required, and should be one of " + strings.Join(changelog.FragmentTypeNames(types), ", ")}
+	}
+	ref := *reference
+	if ref != "" && !strings.HasPrefix(ref, "#") && strings.Trim(ref, "0123456789") == "" {
+		ref = "#" + ref
+	}
+	path, err := changelog.NewFragment(*dir, ref, strings.ToLower(*fragmentType), strings.Join(flags.Args(), " "), types)
+	if err != nil {
+		return err
+	}
+	fmt.Println(path)
+	return nil
+}
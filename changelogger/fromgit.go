@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/parkr/changelog"
+)
+
+var fromGitCommand = &command{
+	name:    "from-git",
+	summary: "Add the commits made since the latest release to the unreleased changes.",
+	run:     runFromGit,
+}
+
+func runFromGit(flags *flag.FlagSet, args []string) error {
+	filename := fileFlags(flags)
+	repoDir := flags.String("repo", ".", "The path to the git repository")
+	since := flags.String("since", "", "The revision after which to read commits, e.g. v4.1.0 (default: the tag of the latest release, or the whole history)")
+	dryRun := flags.Bool("n", false, "Print the changes instead of adding them")
+	conventional := flags.Bool("conventional", false, "Read conventional commits, like \"feat(parser): handle empty lines\", into subsections")
+	types := typeFlag{}
+	flags.Var(types, "type", "Add conventional commits of a type to a subsection, e.g. feat=Features or chore= to skip them; can be repeated")
+	others := flags.String("others", "", "The subsection for commits which aren't conventional commits (default: none)")
+	if err := parseFlags(flags, args, 0, 0); err != nil {
+		return err
+	}
+
+	history, err := readChangelog(*filename)
+	if err != nil {
+		return err
+	}
+	if *since == "" {
+		if latest := history.LatestVersion(); latest != nil {
+			*since = changelog.FindGitTag(*repoDir, latest.Version)
+		}
+	}
+	commits, err := changelog.ReadGitLog(*repoDir, *since)
+	if err != nil {
+		return err
+	}
+
+	var added []*changelog.ChangeLine
+	if *conventional {
+		added = history.AddConventionalCommits(commits, conventionalConfig(history.Dialect, types, *others))
+	} else {
+		added = history.AddCommits(commits)
+	}
+	if *dryRun {
+		for _, line := range added {
+			fmt.Println(line.Format(history.Dialect))
+		}
+		return nil
+	}
+	if len(added) == 0 {
+		fmt.Fprintln(os.Stderr, "No new changes.")
+		return nil
+	}
+	return writeChangelog(history, *filename)
+}
+
+// typeFlag collects -type flags, like "feat=Features".
+type typeFlag map[string]string
+
+func (f typeFlag) String() string {
+	types := []string{}
+	for commitType, subsection := range f {
+		types = append(types, commitType+"="+subsection)
+	}
+	return strings.Join(types, ",")
+}
+
+func (f typeFlag) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return fmt.Errorf("%q should look like TYPE=SUBSECTION", value)
+	}
+	f[strings.ToLower(parts[0])] = parts[1]
+	return nil
+}
+
+// conventionalConfig returns the default configuration for the dialect,
+// with the subsections of the given types and for other commits changed.
+func conventionalConfig(dialect changelog.Dialect, types typeFlag, others string) *changelog.ConventionalConfig {
+	defaults := changelog.JekyllConventionalConfig
+	if dialect == changelog.KeepAChangelog {
+		defaults = changelog.KeepAChangelogConventionalConfig
+	}
+	config := *defaults
+	config.Subsections = map[string]string{}
+	for commitType, subsection := range defaults.Subsections {
+		config.Subsections[commitType] = subsection
+	}
+	for commitType, subsection := range types {
+		if subsection == "" {
+			delete(config.Subsections, commitType)
+		} else {
+			config.Subsections[commitType] = subsection
+		}
+	}
+	config.Others = others
+	return &config
+}
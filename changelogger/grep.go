@@ -0,0 +1,115 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/parkr/changelog"
+)
+
+var grepCommand = &command{
+	name:    "grep",
+	usage:   "[PATTERN]",
+	summary: "Print the changes which match a pattern and filters.",
+	help: `PATTERN is a regular expression matched against the summaries of the changes.
+The filters narrow the search down further, e.g. to list every security fix
+released since 2024:
+
+	changelogger grep -section Security -since 2024
+
+The tsv output has the columns version, date, subsection, summary and
+reference, after a header row. Exits with 1 if nothing matches.`,
+	run: runGrep,
+}
+
+var dateBoundRegexp = regexp.MustCompile(`^\d{4}(-\d{2}(-\d{2})?)?$`)
+
+func runGrep(flags *flag.FlagSet, args []string) error {
+	filename := fileFlags(flags)
+	output := flags.String("o", "markdown", "The output format: markdown, json or tsv")
+	ignoreCase := flags.Bool("i", false, "Match PATTERN case-insensitively")
+	sections, kinds, authors, keywords := &listFlag{}, &listFlag{}, &listFlag{}, &listFlag{}
+	flags.Var(sections, "section", `Only search this subsection, or "" for the changes outside of one; can be repeated`)
+	flags.Var(kinds, "kind", "Only print the changes with a reference of this kind: issue, pull-request, user, commit, url, cross-repo or other; can be repeated")
+	flags.Var(authors, "author", "Only print the changes which mention this user, e.g. @parkr; can be repeated")
+	flags.Var(keywords, "keyword", "Only print the changes whose summary contains this word; can be repeated to require several")
+	since := flags.String("since", "", "Only search the releases dated on or after this year, month or day, e.g. 2024 or 2024-06-30")
+	until := flags.String("until", "", "Only search the releases dated on or before this year, month or day")
+	if err := parseFlags(flags, args, 0, 1); err != nil {
+		return err
+	}
+	switch *output {
+	case "markdown", "json", "tsv":
+	default:
+		return usageError{fmt.Sprintf("unknown output format %q", *output)}
+	}
+
+	filter := &changelog.Filter{
+		Subsections: *sections,
+		Authors:     *authors,
+		Keywords:    *keywords,
+		Since:       *since,
+		Until:       *until,
+	}
+	for _, name := range *kinds {
+		kind, err := changelog.ParseReferenceKind(name)
+		if err != nil {
+			return usageError{err.Error()}
+		}
+		filter.ReferenceKinds = append(filter.ReferenceKinds, kind)
+	}
+	for _, date := range []string{*since, *until} {
+		if date != "" && !dateBoundRegexp.MatchString(date) {
+			return usageError{fmt.Sprintf("invalid date %q, expected e.g. 2024, 2024-06 or 2024-06-30", date)}
+		}
+	}
+	if pattern := flags.Arg(0); pattern != "" {
+		if *ignoreCase {
+			pattern = "(?i)" + pattern
+		}
+		var err error
+		if filter.Pattern, err = regexp.Compile(pattern); err != nil {
+			return usageError{fmt.Sprintf("invalid pattern: %s", err)}
+		}
+	}
+
+	history, err := readChangelog(*filename)
+	if err != nil {
+		return err
+	}
+	matches := history.Search(filter)
+
+	switch *output {
+	case "json":
+		if err := writeJSON(matches, "-"); err != nil {
+			return err
+		}
+	case "tsv":
+		fmt.Println("version\tdate\tsubsection\tsummary\treference")
+		for _, match := range matches {
+			subsection := ""
+			if match.Subsection != nil {
+				subsection = match.Subsection.Name
+			}
+			fields := []string{match.Version.Version, match.Version.Date, subsection, match.Line.Summary, match.Line.Reference}
+			for i, field := range fields {
+				fields[i] = strings.Join(strings.Fields(field), " ")
+			}
+			fmt.Println(strings.Join(fields, "\t"))
+		}
+	default:
+		versions := make([]string, 0, len(matches))
+		for _, version := range matches.Versions() {
+			versions = append(versions, history.FormatVersion(version, true))
+		}
+		if len(versions) > 0 {
+			fmt.Println(strings.Join(versions, "\n\n"))
+		}
+	}
+	if len(matches) == 0 {
+		return errFailed
+	}
+	return nil
+}
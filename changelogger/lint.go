@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/parkr/changelog"
+)
+
+var lintCommand = &command{
+	name:    "lint",
+	summary: "Check the changelog's structure and conventions.",
+	run:     runLint,
+}
+
+// ruleFlag collects -rule flags, like "missing-reference=off".
+type ruleFlag map[string]changelog.Severity
+
+func (f ruleFlag) String() string {
+	rules := []string{}
+	for id, severity := range f {
+		rules = append(rules, id+"="+severity.String())
+	}
+	return strings.Join(rules, ",")
+}
+
+func (f ruleFlag) Set(value string) error {
+	for _, rule := range strings.Split(value, ",") {
+		parts := strings.SplitN(rule, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("%q should look like RULE=SEVERITY", rule)
+		}
+		if !isLintRule(parts[0]) {
+			return fmt.Errorf("unknown rule %q", parts[0])
+		}
+		severity, err := changelog.SeverityByName(parts[1])
+		if err != nil {
+			return err
+		}
+		f[parts[0]] = severity
+	}
+	return nil
+}
+
+func isLintRule(id string) bool {
+	for _, rule := range changelog.LintRules {
+		if rule.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func runLint(flags *flag.FlagSet, args []string) error {
+	filename := fileFlags(flags)
+	rules := ruleFlag{}
+	flags.Var(rules, "rule", "Set the severity of a rule, e.g. missing-reference=off or empty-subsection=error; can be repeated")
+	subsections := flags.String("subsections", "", "A comma-separated list of the known subsection names (default: the dialect's)")
+	failOn := flags.String("fail-on", "warning", "Fail if there are findings of this severity or higher: info, warning or error")
+	listRules := flags.Bool("rules", false, "List the rules and their default severities")
+	if err := parseFlags(flags, args, 0, 0); err != nil {
+		return err
+	}
+	threshold, err := changelog.SeverityByName(*failOn)
+	if err != nil {
+		return usageError{err.Error()}
+	}
+
+	if *listRules {
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, rule := range changelog.LintRules {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", rule.ID, rule.Severity, rule.Description)
+		}
+		return w.Flush()
+	}
+
+	config := &changelog.LintConfig{Severities: rules}
+	if *subsections != "" {
+		for _, name := range strings.Split(*subsections, ",") {
+			config.Subsections = append(config.Subsections, strings.TrimSpace(name))
+		}
+	}
+	source, err := readSource(*filename)
+	if err != nil {
+		return err
+	}
+	findings, err := changelog.LintReader(bytes.NewReader(source), config)
+	if err != nil {
+		return err
+	}
+
+	failed := false
+	for _, finding := range findings {
+		fmt.Printf("%s:%d: %s: %s (%s)\n", displayName(*filename), finding.Line, finding.Severity, finding.Message, finding.Rule)
+		if finding.Severity >= threshold {
+			failed = true
+		}
+	}
+	if failed {
+		return errFailed
+	}
+	return nil
+}
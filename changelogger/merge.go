@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/parkr/changelog"
+)
+
+var mergeDriverCommand = &command{
+	name:    "merge-driver",
+	usage:   "BASE OURS THEIRS [PATH]",
+	summary: "Merge two changelogs changed from BASE into OURS, as a git merge driver.",
+	help: `The changes made in OURS and in THEIRS since BASE are merged into OURS.
+Conflicts are listed, marked in the merged changelog the way git marks
+them, and make the command fail.
+PATH is the name of the changelog in messages.
+
+To merge History.markdown this way, add to .gitattributes:
+
+	History.markdown merge=changelog
+
+and to your git config:
+
+	git config merge.changelog.name "changelogger merge-driver"
+	git config merge.changelog.driver "changelogger merge-driver %O %A %B %P"`,
+	run: runMergeDriver,
+}
+
+func runMergeDriver(flags *flag.FlagSet, args []string) error {
+	out := flags.String("out", "", "Where to write the merged changelog, or - for stdout (default: OURS)")
+	flags.Bool("v", false, "Whether to print verbose output")
+	if err := parseFlags(flags, args, 3, 4); err != nil {
+		return err
+	}
+	oursFilename, name := flags.Arg(1), flags.Arg(3)
+	if name == "" {
+		name = oursFilename
+	}
+	if *out == "" {
+		*out = oursFilename
+	}
+
+	changelogs := make([]*changelog.Changelog, 3)
+	for i := range changelogs {
+		var err error
+		if changelogs[i], err = changelog.NewChangelogFromFile(flags.Arg(i)); err != nil {
+			return err
+		}
+	}
+	merged, conflicts := changelog.MergeWithMarkers(changelogs[0], changelogs[1], changelogs[2])
+	if err := writeOutput([]byte(merged), *out); err != nil {
+		return err
+	}
+	for _, conflict := range conflicts {
+		fmt.Fprintf(os.Stderr, "%s: conflict in %s\n", name, conflict)
+	}
+	if len(conflicts) > 0 {
+		return errFailed
+	}
+	return nil
+}
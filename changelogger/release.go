@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/parkr/changelog"
+)
+
+var releaseCommand = &command{
+	name:    "release",
+	usage:   "VERSION",
+	summary: "Release the unreleased changes as the given version.",
+	run:     runRelease,
+}
+
+var nextVersionCommand = &command{
+	name:    "next-version",
+	summary: "Print the version to release the unreleased changes as.",
+	run:     runNextVersion,
+}
+
+func runRelease(flags *flag.FlagSet, args []string) error {
+	filename := fileFlags(flags)
+	date := flags.String("date", time.Now().Format("2006-01-02"), "The date of the release")
+	if err := parseFlags(flags, args, 1, 1); err != nil {
+		return err
+	}
+
+	history, err := readChangelog(*filename)
+	if err != nil {
+		return err
+	}
+	if err := history.Release(flags.Arg(0), *date); err != nil {
+		return err
+	}
+	return writeChangelog(history, *filename)
+}
+
+func runNextVersion(flags *flag.FlagSet, args []string) error {
+	filename := fileFlags(flags)
+	printBump := flags.Bool("bump", false, "Print the bump (major, minor or patch) instead of the version")
+	if err := parseFlags(flags, args, 0, 0); err != nil {
+		return err
+	}
+
+	history, err := readChangelog(*filename)
+	if err != nil {
+		return err
+	}
+	if *printBump {
+		unreleased := history.GetUnreleased()
+		if unreleased == nil {
+			return fmt.Errorf("there are no unreleased changes")
+		}
+		fmt.Println(changelog.DefaultBumpRules.Bump(unreleased))
+		return nil
+	}
+	version, err := history.NextVersion()
+	if err != nil {
+		return err
+	}
+	fmt.Println(version)
+	return nil
+}
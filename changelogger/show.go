@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/parkr/changelog"
+)
+
+var showCommand = &command{
+	name:    "show",
+	usage:   "[VERSION | HEAD | latest]",
+	summary: "Print the changes in a version, e.g. for the notes of a release.",
+	run:     runShow,
+}
+
+func runShow(flags *flag.FlagSet, args []string) error {
+	filename := fileFlags(flags)
+	noHeader := flags.Bool("no-header", false, "Leave out the version header")
+	output := flags.String("o", "markdown", "The output format: markdown, text or json")
+	repo := flags.String("repo", "", "The URL of the repository to link references like #123 to, e.g. https://github.com/parkr/changelog")
+	forgeName := flags.String("forge", "", "The forge hosting -repo: github, gitlab, gitea or bitbucket (default: detected from the URL)")
+	if err := parseFlags(flags, args, 0, 1); err != nil {
+		return err
+	}
+	switch *output {
+	case "markdown", "text", "json":
+	default:
+		return usageError{fmt.Sprintf("unknown output format %q", *output)}
+	}
+	versionNum := flags.Arg(0)
+	if versionNum == "" {
+		versionNum = "latest"
+	}
+
+	history, err := readChangelog(*filename)
+	if err != nil {
+		return err
+	}
+	version := findVersion(history, versionNum)
+	if version == nil {
+		return fmt.Errorf("no version %s in %s", versionNum, displayName(*filename))
+	}
+	if *repo != "" {
+		resolver, err := newResolver(*repo, *forgeName)
+		if err != nil {
+			return usageError{err.Error()}
+		}
+		version = version.Linkify(resolver, *output == "markdown")
+	}
+
+	switch *output {
+	case "text":
+		fmt.Println(version.Text(!*noHeader))
+	case "json":
+		return writeJSON(version, "-")
+	default:
+		fmt.Println(history.FormatVersion(version, !*noHeader))
+	}
+	return nil
+}
+
+// findVersion fetches the version by its number, which can also be
+// "HEAD" or "unreleased" for the unreleased changes, or "latest" for the
+// latest release.
+func findVersion(history *changelog.Changelog, versionNum string) *changelog.Version {
+	switch strings.ToLower(strings.Trim(versionNum, "[]")) {
+	case "head", "unreleased":
+		return history.GetUnreleased()
+	case "latest":
+		if latest := history.LatestVersion(); latest != nil {
+			return latest
+		}
+		// Without semantic versions, the latest release is the first one.
+		for _, version := range history.Versions {
+			if version.Version != "" && history.GetUnreleased() != version {
+				return version
+			}
+		}
+		return nil
+	}
+	if version := history.GetVersion(versionNum); version != nil {
+		return version
+	}
+	// Allow "v4.1.0" or "[4.1.0]" for "4.1.0", and the other way around.
+	normalize := func(versionNum string) string {
+		return strings.TrimPrefix(strings.Trim(versionNum, "[]"), "v")
+	}
+	for _, version := range history.Versions {
+		if normalize(version.Version) == normalize(versionNum) {
+			return version
+		}
+	}
+	return nil
+}
+
+// newResolver returns the Resolver for the repository on the named forge,
+// or on the forge detected from the URL if forgeName is empty.
+func newResolver(repoURL, forgeName string) (changelog.Resolver, error) {
+	if forgeName == "" {
+		return changelog.NewResolver(repoURL)
+	}
+	forge, err := changelog.ForgeByName(forgeName)
+	if err != nil {
+		return nil, err
+	}
+	return forge.Resolver(repoURL)
+}
@@ -5,14 +5,24 @@ import (
 	"strings"
 )
 
+// isUnreleased checks whether the version number names the changes which
+// haven't been released yet, e.g. "HEAD" or "[Unreleased]".
+func isUnreleased(versionNum string) bool {
+	switch strings.ToUpper(strings.TrimSpace(versionNum)) {
+	case "HEAD", "[UNRELEASED]":
+		return true
+	}
+	return false
+}
+
 // NewVersion allocates a new Version struct with all the fields
 // initialized except {{.Date}}.
 func NewVersion(versionNum string) *Version {
 	var sortOrder int
-	switch strings.ToUpper(strings.TrimSpace(versionNum)) {
-	case "":
+	switch {
+	case strings.TrimSpace(versionNum) == "":
 		sortOrder = -1
-	case "HEAD", "[UNRELEASED]":
+	case isUnreleased(versionNum):
 		sortOrder = 0
 	default:
 		sortOrder = 1
@@ -38,18 +48,25 @@ func (c *Changelog) GetVersion(versionNum string) *Version {
 
 // GetVersion fetches the Version struct which matches the versionNum.
 // If no version was found matching the given versionNum, it creates and
-// saves it to the Changelog.
+// saves it to the Changelog. New releases are placed according to their
+// semantic version, e.g. "2.0.0" above "1.4.2".
 func (c *Changelog) GetVersionOrCreate(versionNum string) *Version {
 	version := c.GetVersion(versionNum)
 	if version == nil {
 		version = NewVersion(versionNum)
-		if len(c.Versions) > 0 && version.sortOrder > 0 && c.Versions[len(c.Versions)-1].sortOrder > 0 {
-			logVerbose("previous sortOrder:", c.Versions[len(c.Versions)-1].sortOrder, "version:", c.Versions[len(c.Versions)-1].Version)
-			version.sortOrder = c.Versions[len(c.Versions)-1].sortOrder + 1
-		}
-		logVerbose("sortOrder:", version.sortOrder, "version:", version.Version)
-		c.Versions = append(c.Versions, version)
-		c.sortVersions()
+		c.insertVersion(version)
+	}
+	return version
+}
+
+// getVersionOrAppend fetches the Version struct which matches the
+// versionNum. If no version was found matching the given versionNum, it
+// creates and saves it to the end of the Changelog.
+func (c *Changelog) getVersionOrAppend(versionNum string) *Version {
+	version := c.GetVersion(versionNum)
+	if version == nil {
+		version = NewVersion(versionNum)
+		c.appendVersion(version)
 	}
 	return version
 }
@@ -79,16 +96,36 @@ func (c *Changelog) GetSubsection(versionNum, subsectionName string) *Subsection
 // GetSubsection fetches the Subsection struct which matches the versionNum & subsectionName.
 // If no subsection was found matching the given versionNum & subsectionName, it creates it and
 // saves it to the Changelog.
+//
+// New Keep a Changelog subsections, like "Added" or "Fixed," are placed in
+// the order recommended by Keep a Changelog relative to the existing ones.
+// Other subsections are added to the end.
 func (c *Changelog) GetSubsectionOrCreate(versionNum, subsectionName string) *Subsection {
 	version := c.GetVersionOrCreate(versionNum)
 	subsection := c.GetSubsection(versionNum, subsectionName)
 	if subsection == nil {
 		subsection = NewSubsection(subsectionName)
-		version.Subsections = append(version.Subsections, subsection)
+		version.Subsections = insertSubsection(version.Subsections, subsection)
 	}
 	return subsection
 }
 
+// insertSubsection adds the subsection to the end of the list, or before
+// the first Keep a Changelog subsection which is recommended to follow it.
+func insertSubsection(subsections []*Subsection, subsection *Subsection) []*Subsection {
+	if position, ok := isKeepAChangelogSubsection(subsection.Name); ok {
+		for i, existing := range subsections {
+			if existingPosition, ok := isKeepAChangelogSubsection(existing.Name); ok && existingPosition > position {
+				subsections = append(subsections, nil)
+				copy(subsections[i+1:], subsections[i:])
+				subsections[i] = subsection
+				return subsections
+			}
+		}
+	}
+	return append(subsections, subsection)
+}
+
 // AddLineToVersion adds a ChangeLine to the given version's direct
 // history. This is only to be used when it is inappropriate to add it to a
 // subsection, or the version's changes don't warrant subsections.
@@ -114,6 +151,30 @@ func (c *Changelog) AddLineToSubsection(versionNum, subsectionName string, line
 	c.addToChangelines(&s.History, line)
 }
 
+// HasLine checks whether the given version's subsection already has a
+// change with the same summary and reference. If subsectionName is empty,
+// the version's direct history is checked instead.
+func (c *Changelog) HasLine(versionNum, subsectionName string, line *ChangeLine) bool {
+	if line == nil {
+		return false
+	}
+
+	var lines []*ChangeLine
+	if subsectionName == "" {
+		if version := c.GetVersion(versionNum); version != nil {
+			lines = version.History
+		}
+	} else if subsection := c.GetSubsection(versionNum, subsectionName); subsection != nil {
+		lines = subsection.History
+	}
+	for _, existing := range lines {
+		if existing.Summary == line.Summary && existing.Reference == line.Reference {
+			return true
+		}
+	}
+	return false
+}
+
 // addToChangelines adds a given ChangeLine to the array of ChangeLines.
 func (c *Changelog) addToChangelines(lines *[]*ChangeLine, line *ChangeLine) {
 	if line == nil || lines == nil {
@@ -128,3 +189,11 @@ func (c *Changelog) sortVersions() {
 		return c.Versions[i].sortOrder < c.Versions[j].sortOrder
 	})
 }
+
+// sortedVersions returns a sorted copy of the versions, leaving the
+// changelog as it is.
+func (c *Changelog) sortedVersions() []*Version {
+	sorted := &Changelog{Versions: append([]*Version{}, c.Versions...)}
+	sorted.sortVersions()
+	return sorted.Versions
+}
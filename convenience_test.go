@@ -129,3 +129,20 @@ func TestAddLineToSubsection(t *testing.T) {
 	c.AddLineToSubsection(versionNum, subsectionName, &line)
 	assert.Equal(t, "## HEAD\n\n### Bug Fixes\n\n  * Added a fun method. (#23)\n", c.String())
 }
+
+func TestHasLine(t *testing.T) {
+	c := NewChangelog()
+	line := &ChangeLine{Summary: "Fix the tokenizer", Reference: "#123"}
+	assert.False(t, c.HasLine("HEAD", "Bug Fixes", line))
+
+	c.AddLineToSubsection("HEAD", "Bug Fixes", line)
+	assert.True(t, c.HasLine("HEAD", "Bug Fixes", &ChangeLine{Summary: "Fix the tokenizer", Reference: "#123"}))
+	assert.False(t, c.HasLine("HEAD", "Bug Fixes", &ChangeLine{Summary: "Fix the tokenizer", Reference: "#124"}))
+	assert.False(t, c.HasLine("HEAD", "Bug Fixes", &ChangeLine{Summary: "Fix the tokenizer"}))
+	assert.False(t, c.HasLine("HEAD", "Minor Enhancements", line))
+	assert.False(t, c.HasLine("HEAD", "", line))
+	assert.False(t, c.HasLine("HEAD", "Bug Fixes", nil))
+
+	c.AddLineToVersion("1.0.0", &ChangeLine{Summary: "Initial release"})
+	assert.True(t, c.HasLine("1.0.0", "", &ChangeLine{Summary: "Initial release"}))
+}
@@ -0,0 +1,165 @@
+package changelog
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ConventionalCommit is a commit message which follows the Conventional
+// Commits specification, https://www.conventionalcommits.org, e.g.
+// "feat(parser)!: drop support for Ruby 1.9".
+type ConventionalCommit struct {
+	// Type is the kind of change, e.g. "feat" or "fix".
+	Type string
+	// Scope is the part of the project which changed, e.g. "parser".
+	Scope       string
+	Description string
+	// Breaking is set for a change which breaks backwards compatibility,
+	// marked with a "!" after the type or scope, or with a
+	// "BREAKING CHANGE:" footer.
+	Breaking bool
+}
+
+var (
+	conventionalSubjectRegexp  = regexp.MustCompile(`^([[:alpha:]][\w-]*)(?:\(([^()]+)\))?(!)?: +(\S.*)$`)
+	conventionalBreakingRegexp = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE: `)
+)
+
+// ParseConventionalCommit parses a commit message with the given subject
+// and body. Returns false if the subject doesn't follow the Conventional
+// Commits specification.
+func ParseConventionalCommit(subject, body string) (*ConventionalCommit, bool) {
+	matches := conventionalSubjectRegexp.FindStringSubmatch(subject)
+	if matches == nil {
+		return nil, false
+	}
+	return &ConventionalCommit{
+		Type:        strings.ToLower(matches[1]),
+		Scope:       matches[2],
+		Description: matches[4],
+		Breaking:    matches[3] != "" || conventionalBreakingRegexp.MatchString(body),
+	}, true
+}
+
+// ConventionalConfig configures how conventional commits are added to a
+// changelog.
+type ConventionalConfig struct {
+	// Subsections maps commit types, like "feat", to the subsection their
+	// changes go in. Commits with other types are skipped.
+	Subsections map[string]string
+	// Breaking is the subsection breaking changes go in, whatever their
+	// type. If empty, they go in the subsection for their type.
+	Breaking string
+	// BreakingPrefix is put before the summary of breaking changes, e.g.
+	// "BREAKING: ".
+	BreakingPrefix string
+	// Scopes puts the scope of the commit before the summary, e.g.
+	// "parser: Handle empty lines".
+	Scopes bool
+	// Others is the subsection for commits which aren't conventional
+	// commits. If empty, they go in the version's direct history.
+	Others string
+}
+
+var (
+	// JekyllConventionalConfig adds conventional commits to the
+	// subsections used by Jekyll.
+	JekyllConventionalConfig = &ConventionalConfig{
+		Subsections: map[string]string{
+			"feat":     "Minor Enhancements",
+			"perf":     "Minor Enhancements",
+			"fix":      "Bug Fixes",
+			"revert":   "Bug Fixes",
+			"docs":     "Documentation",
+			"build":    "Development Fixes",
+			"ci":       "Development Fixes",
+			"chore":    "Development Fixes",
+			"refactor": "Development Fixes",
+			"style":    "Development Fixes",
+			"test":     "Development Fixes",
+		},
+		Breaking: "Major Enhancements",
+		Scopes:   true,
+	}
+
+	// KeepAChangelogConventionalConfig adds conventional commits to the
+	// subsections of Keep a Changelog, which only lists the changes users
+	// notice.
+	KeepAChangelogConventionalConfig = &ConventionalConfig{
+		Subsections: map[string]string{
+			"feat":      Added,
+			"perf":      Changed,
+			"refactor":  Changed,
+			"revert":    Changed,
+			"deprecate": Deprecated,
+			"remove":    Removed,
+			"fix":       Fixed,
+			"security":  Security,
+		},
+		Breaking:       Changed,
+		BreakingPrefix: "BREAKING: ",
+		Scopes:         true,
+	}
+)
+
+// conventionalConfig returns the default ConventionalConfig for the
+// dialect.
+func conventionalConfig(d Dialect) *ConventionalConfig {
+	if d == KeepAChangelog {
+		return KeepAChangelogConventionalConfig
+	}
+	return JekyllConventionalConfig
+}
+
+// AddConventionalCommits adds a change for each of the commits to the
+// unreleased changes, oldest first, like AddCommits. Conventional commits
+// are added to the subsection for their type using AddLineToSubsection,
+// with the description as the summary. If config is nil, the default
+// configuration for the changelog's Dialect is used. Returns the changes
+// which were added.
+func (c *Changelog) AddConventionalCommits(commits []*Commit, config *ConventionalConfig) []*ChangeLine {
+	if config == nil {
+		config = conventionalConfig(c.dialect())
+	}
+	return c.addCommits(commits, func(versionNum string, commit *Commit, line *ChangeLine) bool {
+		// The summary of a pull request's merge commit is its title, which
+		// is where the conventional commit is.
+		conventional, ok := ParseConventionalCommit(line.Summary, commit.Body)
+		if !ok {
+			if config.Others == "" {
+				c.AddLineToVersion(versionNum, line)
+			} else {
+				c.AddLineToSubsection(versionNum, config.Others, line)
+			}
+			return true
+		}
+
+		subsection, ok := config.Subsections[conventional.Type]
+		if conventional.Breaking && config.Breaking != "" {
+			subsection, ok = config.Breaking, true
+		}
+		if !ok {
+			return false
+		}
+		line.Summary = capitalize(conventional.Description)
+		if config.Scopes && conventional.Scope != "" {
+			line.Summary = conventional.Scope + ": " + line.Summary
+		}
+		if conventional.Breaking {
+			line.Summary = config.BreakingPrefix + line.Summary
+		}
+		c.AddLineToSubsection(versionNum, subsection, line)
+		return true
+	})
+}
+
+// capitalize upper-cases the first letter of the text.
+func capitalize(text string) string {
+	if text == "" {
+		return text
+	}
+	r, size := utf8.DecodeRuneInString(text)
+	return string(unicode.ToUpper(r)) + text[size:]
+}
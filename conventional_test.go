@@ -0,0 +1,113 @@
+package changelog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseConventionalCommit(t *testing.T) {
+	testCases := []struct {
+		subject, body string
+		expected      *ConventionalCommit
+	}{
+		{"feat: add a linter", "", &ConventionalCommit{Type: "feat", Description: "add a linter"}},
+		{"feat(parser): handle empty lines", "", &ConventionalCommit{Type: "feat", Scope: "parser", Description: "handle empty lines"}},
+		{"fix!: drop the old tokenizer", "", &ConventionalCommit{Type: "fix", Description: "drop the old tokenizer", Breaking: true}},
+		{"Refactor(cli)!: rename flags", "", &ConventionalCommit{Type: "refactor", Scope: "cli", Description: "rename flags", Breaking: true}},
+		{"feat: new output format", "Adds JSON.\n\nBREAKING CHANGE: text is no longer the default", &ConventionalCommit{Type: "feat", Description: "new output format", Breaking: true}},
+		{"feat: new output format", "BREAKING-CHANGE: text is no longer the default", &ConventionalCommit{Type: "feat", Description: "new output format", Breaking: true}},
+		{"feat: new output format", "Not a BREAKING CHANGE: really", &ConventionalCommit{Type: "feat", Description: "new output format"}},
+	}
+	for _, testCase := range testCases {
+		commit, ok := ParseConventionalCommit(testCase.subject, testCase.body)
+		assert.True(t, ok, testCase.subject)
+		assert.Equal(t, testCase.expected, commit, testCase.subject)
+	}
+
+	for _, subject := range []string{"Add a linter", "feat:missing space", "feat(): empty scope", "Merge pull request #1 from parkr/fix"} {
+		_, ok := ParseConventionalCommit(subject, "")
+		assert.False(t, ok, subject)
+	}
+}
+
+func conventionalCommits() []*Commit {
+	// Newest first, like git log.
+	return []*Commit{
+		{SHA: "a000006", Subject: "Update the README", Parents: 1},
+		{SHA: "a000005", Subject: "chore: bump dependencies", Parents: 1},
+		{SHA: "a000004", Subject: "feat(cli)!: rename -out to -o (#7)", Parents: 1},
+		{SHA: "a000003", Subject: "docs: explain dialects", Parents: 1},
+		{SHA: "a000002", Subject: "Merge pull request #5 from parkr/tokenizer", Body: "fix(parser): handle empty lines", Parents: 2},
+		{SHA: "a000001", Subject: "feat: add a linter", Parents: 1},
+	}
+}
+
+func TestAddConventionalCommits_Jekyll(t *testing.T) {
+	history := NewChangelog()
+	added := history.AddConventionalCommits(conventionalCommits(), nil)
+
+	assert.Len(t, added, 6)
+	assert.Equal(t, `## HEAD
+
+  * Update the README (a000006)
+
+### Minor Enhancements
+
+  * Add a linter (a000001)
+
+### Bug Fixes
+
+  * parser: Handle empty lines (#5)
+
+### Documentation
+
+  * Explain dialects (a000003)
+
+### Major Enhancements
+
+  * cli: Rename -out to -o (#7)
+
+### Development Fixes
+
+  * Bump dependencies (a000005)
+`, history.String())
+}
+
+func TestAddConventionalCommits_KeepAChangelog(t *testing.T) {
+	history := NewChangelog()
+	history.SetDialect(KeepAChangelog)
+	history.GetVersionOrCreate("[Unreleased]")
+	added := history.AddConventionalCommits(conventionalCommits(), nil)
+
+	assert.Len(t, added, 4)
+	assert.Equal(t, `## [Unreleased]
+
+- Update the README (a000006)
+
+### Added
+
+- Add a linter (a000001)
+
+### Changed
+
+- BREAKING: cli: Rename -out to -o (#7)
+
+### Fixed
+
+- parser: Handle empty lines (#5)
+`, history.String())
+	assert.Equal(t, BumpMajor, DefaultBumpRules.Bump(history.GetUnreleased()))
+}
+
+func TestAddConventionalCommits_Config(t *testing.T) {
+	history := NewChangelog()
+	added := history.AddConventionalCommits(conventionalCommits(), &ConventionalConfig{
+		Subsections: map[string]string{"feat": "Features", "fix": "Fixes"},
+		Others:      "Other Changes",
+	})
+
+	assert.Len(t, added, 4)
+	assert.Equal(t, []string{"Features", "Fixes", "Other Changes"}, subsectionNames(history.GetVersion("HEAD")))
+	assert.Equal(t, "Rename -out to -o", history.GetSubsection("HEAD", "Features").History[1].Summary)
+}
@@ -0,0 +1,171 @@
+package changelog
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Dialect is a set of conventions for writing a changelog in markdown,
+// such as the format of the version headers and of the list of changes.
+// The structure of the changelog is the same for every dialect; only the
+// individual lines differ.
+type Dialect interface {
+	// Name identifies the dialect, e.g. "jekyll".
+	Name() string
+	// DefaultStyle returns the style used by the dialect when the
+	// changelog doesn't specify one.
+	DefaultStyle() Style
+	// VersionHeader returns the header line for the version, or an empty
+	// string if the version has no header.
+	VersionHeader(v *Version, style *Style) string
+	// SubsectionHeader returns the header line for the subsection.
+	SubsectionHeader(s *Subsection, style *Style) string
+	// ChangeLine returns the list item for the change.
+	ChangeLine(l *ChangeLine, style *Style) string
+	// Unreleased is the name of the version which collects the changes
+	// that haven't been released yet.
+	Unreleased() string
+	// LinksVersions reports whether every version is expected to link to
+	// its release, so that new versions get a link automatically.
+	LinksVersions() bool
+	// KeepsUnreleased reports whether the changelog always has a section
+	// for unreleased changes, even when it's empty.
+	KeepsUnreleased() bool
+}
+
+var (
+	// Jekyll is the dialect used by Jekyll's History.markdown:
+	//
+	//	## HEAD
+	//
+	//	### Bug Fixes
+	//
+	//	  * Fix the tokenizer (#123)
+	//
+	//	## 1.0.0 / 2015-02-20
+	Jekyll Dialect = jekyllDialect{}
+
+	// KeepAChangelog is the dialect described at https://keepachangelog.com:
+	//
+	//	## [Unreleased]
+	//
+	//	### Fixed
+	//
+	//	- Fix the tokenizer (#123)
+	//
+	//	## [1.0.0] - 2015-02-20
+	KeepAChangelog Dialect = keepAChangelogDialect{}
+
+	// Dialects lists the built-in dialects.
+	Dialects = []Dialect{Jekyll, KeepAChangelog}
+)
+
+// DialectByName fetches the built-in Dialect with the given name. The
+// comparison is case-insensitive and ignores dashes, so both
+// "keepachangelog" and "keep-a-changelog" are accepted.
+func DialectByName(name string) (Dialect, error) {
+	normalized := strings.ToLower(strings.ReplaceAll(name, "-", ""))
+	for _, dialect := range Dialects {
+		if dialect.Name() == normalized {
+			return dialect, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown changelog dialect %q", name)
+}
+
+// formatChangeLine returns the list item for the change with the bullet
+// of the given style. If the summary spans several lines, the reference is
+// written at the end of the first one.
+func formatChangeLine(l *ChangeLine, style *Style) string {
+	summary, rest := l.Summary, ""
+	if i := strings.Index(summary, "\n"); i >= 0 {
+		summary, rest = summary[:i], summary[i:]
+	}
+	str := style.bullet() + summary
+	if reference := l.writtenReference(); reference != "" {
+		str += " (" + reference + ")"
+	}
+	return str + rest
+}
+
+type jekyllDialect struct{}
+
+func (jekyllDialect) Name() string { return "jekyll" }
+
+func (jekyllDialect) DefaultStyle() Style {
+	return Style{Bullet: "*", Indent: 2, HeaderDepth: 2, DateSeparator: " / "}
+}
+
+func (jekyllDialect) VersionHeader(v *Version, style *Style) string {
+	header := ""
+	if strings.EqualFold(v.Version, "[Unreleased]") {
+		header += style.header() + "HEAD"
+	} else if v.Version != "" {
+		header += style.header() + v.Version
+	}
+	if v.Date != "" {
+		header += style.DateSeparator + v.Date
+	}
+	if v.Yanked {
+		header += " [YANKED]"
+	}
+	return header
+}
+
+func (jekyllDialect) SubsectionHeader(s *Subsection, style *Style) string {
+	return "### " + s.Name
+}
+
+func (jekyllDialect) ChangeLine(l *ChangeLine, style *Style) string {
+	return formatChangeLine(l, style)
+}
+
+func (jekyllDialect) Unreleased() string { return "HEAD" }
+
+func (jekyllDialect) LinksVersions() bool { return false }
+
+func (jekyllDialect) KeepsUnreleased() bool { return false }
+
+type keepAChangelogDialect struct{}
+
+func (keepAChangelogDialect) Name() string { return "keepachangelog" }
+
+func (keepAChangelogDialect) DefaultStyle() Style {
+	return Style{Bullet: "-", Indent: 0, HeaderDepth: 2, DateSeparator: " - "}
+}
+
+func (keepAChangelogDialect) VersionHeader(v *Version, style *Style) string {
+	if v.Version == "" {
+		return ""
+	}
+	header := style.header()
+	switch {
+	case strings.EqualFold(v.Version, "HEAD"):
+		header += "[Unreleased]"
+	case strings.HasPrefix(v.Version, "["):
+		header += v.Version
+	default:
+		header += "[" + v.Version + "]"
+	}
+	if v.Date != "" {
+		header += style.DateSeparator + v.Date
+	}
+	if v.Yanked {
+		header += " [YANKED]"
+	}
+	return header
+}
+
+func (keepAChangelogDialect) SubsectionHeader(s *Subsection, style *Style) string {
+	return "### " + s.Name
+}
+
+func (keepAChangelogDialect) ChangeLine(l *ChangeLine, style *Style) string {
+	return formatChangeLine(l, style)
+}
+
+func (keepAChangelogDialect) Unreleased() string { return "[Unreleased]" }
+
+func (keepAChangelogDialect) LinksVersions() bool { return true }
+
+func (keepAChangelogDialect) KeepsUnreleased() bool { return true }
@@ -0,0 +1,78 @@
+package changelog
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialectByName(t *testing.T) {
+	for name, expected := range map[string]Dialect{
+		"jekyll":           Jekyll,
+		"Jekyll":           Jekyll,
+		"keepachangelog":   KeepAChangelog,
+		"keep-a-changelog": KeepAChangelog,
+	} {
+		dialect, err := DialectByName(name)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, dialect, "wrong dialect for %q", name)
+	}
+
+	dialect, err := DialectByName("towncrier")
+	assert.Nil(t, dialect)
+	assert.EqualError(t, err, `unknown changelog dialect "towncrier"`)
+}
+
+func TestDialectDetection(t *testing.T) {
+	history, err := NewChangelogFromFile("testdata/History.markdown")
+	assert.NoError(t, err)
+	assert.Equal(t, Jekyll, history.Dialect)
+
+	history, err = NewChangelogFromFile("testdata/keep-a-changelog.md")
+	assert.NoError(t, err)
+	assert.Equal(t, KeepAChangelog, history.Dialect)
+}
+
+func TestChangelog_WritesWhatItParses_KeepAChangelog(t *testing.T) {
+	expected, err := os.ReadFile("testdata/keep-a-changelog.md")
+	assert.NoError(t, err)
+
+	history, err := NewChangelogFromReader(strings.NewReader(string(expected)))
+	assert.NoError(t, err)
+
+	assert.Equal(t, string(expected), history.String())
+}
+
+func TestChangelogString_ConvertToJekyll(t *testing.T) {
+	history, err := NewChangelogFromFile("testdata/keep-a-changelog.md")
+	assert.NoError(t, err)
+
+	history.SetDialect(Jekyll)
+	actual := history.String()
+
+	assert.Contains(t, actual, "\n## HEAD\n\n### Added\n\n  * v1.1 Brazilian Portuguese translation.\n")
+	assert.Contains(t, actual, "\n## 0.0.5 / 2014-08-09 [YANKED]\n")
+	assert.Contains(t, actual, "\n[HEAD]: https://github.com/olivierlacan/keep-a-changelog/compare/v1.1.1...HEAD\n")
+}
+
+func TestChangelogString_ConvertToKeepAChangelog(t *testing.T) {
+	history := NewChangelog()
+	history.GetVersionOrCreate("1.0.0").Date = "2015-02-20"
+	history.AddLineToSubsection("HEAD", "Bug Fixes", &ChangeLine{Summary: "Fix the tokenizer", Reference: "#123"})
+	history.AddLineToVersion("1.0.0", &ChangeLine{Summary: "Initial release"})
+
+	history.SetDialect(KeepAChangelog)
+
+	assert.Equal(t, `## [Unreleased]
+
+### Bug Fixes
+
+- Fix the tokenizer (#123)
+
+## [1.0.0] - 2015-02-20
+
+- Initial release
+`, history.String())
+}
@@ -0,0 +1,401 @@
+package changelog
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DiffOp is the way something differs between two changelogs.
+type DiffOp int
+
+const (
+	// DiffAdded is something which is only in the new changelog.
+	DiffAdded DiffOp = iota
+	// DiffRemoved is something which is only in the old changelog.
+	DiffRemoved
+	// DiffModified is something which is in both changelogs, but differs.
+	DiffModified
+)
+
+func (op DiffOp) String() string {
+	switch op {
+	case DiffAdded:
+		return "added"
+	case DiffRemoved:
+		return "removed"
+	}
+	return "modified"
+}
+
+// MarshalText encodes the op as its name, e.g. in JSON.
+func (op DiffOp) MarshalText() ([]byte, error) {
+	return []byte(op.String()), nil
+}
+
+// Difference is something which differs between two changelogs: a
+// version, a subsection, a change line, or a detail of a version or
+// subsection, like its date.
+type Difference struct {
+	Op DiffOp `json:"op"`
+	// Version is the version the difference is in, as it is written in
+	// the new changelog if it's in both. It is empty for the preamble and
+	// the epilogue, and for the changes above the first version header.
+	Version string `json:"version,omitempty"`
+	// Subsection is the subsection the difference is in, if any.
+	Subsection string `json:"subsection,omitempty"`
+	// Field is the detail which differs: "preamble", "epilogue", "date",
+	// "yanked", "url" or "description". It is empty if the difference is a
+	// whole version, subsection or change line.
+	Field string `json:"field,omitempty"`
+	// OldValue and NewValue are the values of the Field, if any.
+	OldValue string `json:"oldValue,omitempty"`
+	NewValue string `json:"newValue,omitempty"`
+	// Old and New are the change lines, if the difference is one. Old is
+	// nil for an added line, and New for a removed one.
+	Old *ChangeLine `json:"old,omitempty"`
+	New *ChangeLine `json:"new,omitempty"`
+}
+
+// IsChangeLine reports whether the difference is a change line.
+func (d *Difference) IsChangeLine() bool {
+	return d.Old != nil || d.New != nil
+}
+
+// String describes the difference on one line, e.g. "HEAD, Bug Fixes: +
+// Fix the tokenizer (#123)".
+func (d *Difference) String() string {
+	location := d.Version
+	if location == "" && d.Field != "preamble" && d.Field != "epilogue" {
+		location = "(no version)"
+	}
+	if d.Subsection != "" {
+		location += ", " + d.Subsection
+	}
+	if location != "" {
+		location += ": "
+	}
+	switch {
+	case d.Field != "":
+		return fmt.Sprintf("%s%s changed from %q to %q", location, d.Field, d.OldValue, d.NewValue)
+	case d.Op == DiffModified:
+		return location + "~ " + diffLine(d.Old) + " => " + diffLine(d.New)
+	case d.Old != nil:
+		return location + "- " + diffLine(d.Old)
+	case d.New != nil:
+		return location + "+ " + diffLine(d.New)
+	}
+	return location + d.Op.String()
+}
+
+// diffLine returns the change as it is written, without a list marker.
+func diffLine(l *ChangeLine) string {
+	return strings.TrimPrefix(formatChangeLine(l, &Style{Bullet: "-"}), "- ")
+}
+
+// Differences lists the differences between two changelogs, in the order
+// of the new changelog.
+type Differences []*Difference
+
+// InVersion returns the differences in the given version. "HEAD" and
+// "[Unreleased]" both stand for the unreleased changes.
+func (diffs Differences) InVersion(versionNum string) Differences {
+	key := mergeVersionKey(NewVersion(versionNum))
+	in := Differences{}
+	for _, d := range diffs {
+		if d.Field == "preamble" || d.Field == "epilogue" {
+			continue
+		}
+		if mergeVersionKey(NewVersion(d.Version)) == key {
+			in = append(in, d)
+		}
+	}
+	return in
+}
+
+// String lists the differences, one per line.
+func (diffs Differences) String() string {
+	lines := make([]string, len(diffs))
+	for i, d := range diffs {
+		lines[i] = d.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Markdown lists the differences as markdown, under a header for each
+// version and subsection, e.g. for a comment on a pull request.
+func (diffs Differences) Markdown() string {
+	blocks := []string{}
+	items := []string{}
+	flush := func() {
+		if len(items) > 0 {
+			blocks = append(blocks, strings.Join(items, "\n"))
+			items = []string{}
+		}
+	}
+	for _, d := range diffs {
+		if d.Field == "preamble" || d.Field == "epilogue" {
+			items = append(items, d.markdownItem())
+		}
+	}
+	flush()
+
+	version, subsection := "\x00", "\x00"
+	for _, d := range diffs {
+		if d.Field == "preamble" || d.Field == "epilogue" {
+			continue
+		}
+		if d.Version != version {
+			flush()
+			version, subsection = d.Version, ""
+			title := d.Version
+			if title == "" {
+				title = "(no version)"
+			}
+			if d.Subsection == "" {
+				title += d.markdownOp()
+			}
+			blocks = append(blocks, "## "+title)
+		}
+		if d.Subsection != subsection {
+			flush()
+			subsection = d.Subsection
+			blocks = append(blocks, "### "+d.Subsection+d.markdownOp())
+		}
+		if item := d.markdownItem(); item != "" {
+			items = append(items, item)
+		}
+	}
+	flush()
+	if len(blocks) == 0 {
+		return "No changes.\n"
+	}
+	return strings.Join(blocks, "\n\n") + "\n"
+}
+
+// markdownOp returns what goes after the header of an added or removed
+// version or subsection.
+func (d *Difference) markdownOp() string {
+	if d.Field != "" || d.IsChangeLine() {
+		return ""
+	}
+	switch d.Op {
+	case DiffAdded:
+		return " (added)"
+	case DiffRemoved:
+		return " (removed)"
+	}
+	return ""
+}
+
+// markdownItem returns the list item for the difference, or an empty
+// string for an added or removed version or subsection.
+func (d *Difference) markdownItem() string {
+	switch {
+	case d.Field != "":
+		return fmt.Sprintf("- Changed the %s from %q to %q", d.Field, d.OldValue, d.NewValue)
+	case d.Op == DiffModified:
+		return "- Changed: " + diffLine(d.Old) + " → " + diffLine(d.New)
+	case d.Old != nil:
+		return "- Removed: " + diffLine(d.Old)
+	case d.New != nil:
+		return "- Added: " + diffLine(d.New)
+	}
+	return ""
+}
+
+// differ holds the differences found so far.
+type differ struct {
+	diffs Differences
+}
+
+func (d *differ) add(diff *Difference) {
+	d.diffs = append(d.diffs, diff)
+}
+
+func (d *differ) field(version *Version, subsection, field, oldValue, newValue string) {
+	if oldValue != newValue {
+		d.add(&Difference{
+			Op:         DiffModified,
+			Version:    version.Version,
+			Subsection: subsection,
+			Field:      field,
+			OldValue:   oldValue,
+			NewValue:   newValue,
+		})
+	}
+}
+
+// Diff compares two changelogs, e.g. the one of a pull request with the
+// one it is based on, and returns the differences between their versions,
+// subsections and change lines. Versions are matched by their version
+// number, so that "HEAD" matches "[Unreleased]", and subsections by name.
+// A change line which is only in one changelog is matched with a line of
+// the other which has the same issue or pull request reference or a
+// similar summary, and reported as modified; other lines are added or
+// removed. The lines of added and removed versions and subsections are
+// listed too. Neither changelog is modified.
+func Diff(a, b *Changelog) Differences {
+	d := &differ{diffs: Differences{}}
+	if a.Preamble != b.Preamble {
+		d.add(&Difference{Op: DiffModified, Field: "preamble", OldValue: a.Preamble, NewValue: b.Preamble})
+	}
+
+	oldVersions := versionsByKey(a)
+	newVersions := versionsByKey(b)
+	for _, version := range b.sortedVersions() {
+		d.diffVersion(oldVersions[mergeVersionKey(version)], version)
+	}
+	for _, version := range a.sortedVersions() {
+		if _, ok := newVersions[mergeVersionKey(version)]; !ok {
+			d.diffVersion(version, nil)
+		}
+	}
+
+	if a.Epilogue != b.Epilogue {
+		d.add(&Difference{Op: DiffModified, Field: "epilogue", OldValue: a.Epilogue, NewValue: b.Epilogue})
+	}
+	return d.diffs
+}
+
+// diffVersion compares two versions, either of which can be nil if it's
+// only in one of the changelogs.
+func (d *differ) diffVersion(a, b *Version) {
+	version := b
+	switch {
+	case a == nil:
+		a = &Version{}
+		d.add(&Difference{Op: DiffAdded, Version: b.Version})
+	case b == nil:
+		b, version = &Version{}, a
+		d.add(&Difference{Op: DiffRemoved, Version: a.Version})
+	default:
+		d.field(version, "", "date", a.Date, b.Date)
+		d.field(version, "", "yanked", fmt.Sprint(a.Yanked), fmt.Sprint(b.Yanked))
+		d.field(version, "", "url", a.URL, b.URL)
+		d.field(version, "", "description", a.Description, b.Description)
+	}
+	d.diffChangeLines(version, "", a.History, b.History)
+
+	find := func(subsections []*Subsection, name string) *Subsection {
+		for _, subsection := range subsections {
+			if strings.EqualFold(strings.TrimSpace(subsection.Name), strings.TrimSpace(name)) {
+				return subsection
+			}
+		}
+		return nil
+	}
+	for _, subsection := range b.Subsections {
+		d.diffSubsection(version, find(a.Subsections, subsection.Name), subsection)
+	}
+	for _, subsection := range a.Subsections {
+		if find(b.Subsections, subsection.Name) == nil {
+			d.diffSubsection(version, subsection, nil)
+		}
+	}
+}
+
+// diffSubsection compares two subsections of the version, either of
+// which can be nil.
+func (d *differ) diffSubsection(version *Version, a, b *Subsection) {
+	switch {
+	case a == nil:
+		a = &Subsection{}
+		d.add(&Difference{Op: DiffAdded, Version: version.Version, Subsection: b.Name})
+	case b == nil:
+		b = &Subsection{Name: a.Name}
+		d.add(&Difference{Op: DiffRemoved, Version: version.Version, Subsection: a.Name})
+	default:
+		d.field(version, b.Name, "description", a.Description, b.Description)
+	}
+	d.diffChangeLines(version, b.Name, a.History, b.History)
+}
+
+// diffChangeLines compares two lists of changes. The lines which are in
+// both lists are left out, then the remaining ones are paired up as
+// modifications where possible.
+func (d *differ) diffChangeLines(version *Version, subsection string, a, b []*ChangeLine) {
+	removed := changeLinesNotIn(a, changeLineKeys(b))
+	added := changeLinesNotIn(b, changeLineKeys(a))
+
+	pairs := map[*ChangeLine]*ChangeLine{}
+	paired := map[*ChangeLine]bool{}
+	for _, old := range removed {
+		var best *ChangeLine
+		bestScore := 0.0
+		for _, line := range added {
+			if paired[line] {
+				continue
+			}
+			score := changeLineSimilarity(old, line)
+			if score >= similarityThreshold && score > bestScore {
+				best, bestScore = line, score
+			}
+		}
+		if best != nil {
+			pairs[best] = old
+			paired[best] = true
+			paired[old] = true
+		}
+	}
+
+	for _, line := range b {
+		if old, ok := pairs[line]; ok {
+			d.add(&Difference{Op: DiffModified, Version: version.Version, Subsection: subsection, Old: old, New: line})
+		}
+	}
+	for _, line := range added {
+		if !paired[line] {
+			d.add(&Difference{Op: DiffAdded, Version: version.Version, Subsection: subsection, New: line})
+		}
+	}
+	for _, line := range removed {
+		if !paired[line] {
+			d.add(&Difference{Op: DiffRemoved, Version: version.Version, Subsection: subsection, Old: line})
+		}
+	}
+}
+
+// similarityThreshold is how similar the summaries of two change lines
+// have to be for one to be a modification of the other.
+const similarityThreshold = 0.5
+
+var diffWordRegexp = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// changeLineSimilarity scores how likely the new line is a modification
+// of the old one, from 0 to 1, or 2 if they share an issue, pull request
+// or commit reference.
+func changeLineSimilarity(old, line *ChangeLine) float64 {
+	oldRefs := map[string]bool{}
+	for _, ref := range old.References() {
+		switch ref.Kind {
+		case ReferenceIssue, ReferencePullRequest, ReferenceCommit, ReferenceCrossRepo:
+			oldRefs[ref.Repo+ref.Text] = true
+		}
+	}
+	for _, ref := range line.References() {
+		if oldRefs[ref.Repo+ref.Text] {
+			return 2
+		}
+	}
+
+	// The Sørensen–Dice coefficient of the words of the summaries.
+	words := func(summary string) map[string]bool {
+		set := map[string]bool{}
+		for _, word := range diffWordRegexp.FindAllString(strings.ToLower(summary), -1) {
+			set[word] = true
+		}
+		return set
+	}
+	a, b := words(old.Summary), words(line.Summary)
+	if len(a)+len(b) == 0 {
+		return 0
+	}
+	common := 0
+	for word := range a {
+		if b[word] {
+			common++
+		}
+	}
+	return 2 * float64(common) / float64(len(a)+len(b))
+}
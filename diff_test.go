@@ -0,0 +1,144 @@
+package changelog
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const diffOld = `# Changelog
+
+## HEAD
+
+  * Add a diff command (#10)
+
+### Bug Fixes
+
+  * Fix the tokenizer (#1)
+  * Fix the parser for empty lines
+  * Remove the old renderer
+
+## 1.0.0 / 2015-02-20
+
+### Minor Enhancements
+
+  * Initial release
+`
+
+const diffNew = `# Changelog
+
+## HEAD
+
+  * Add a diff command (#10)
+  * Add a merge driver (#11)
+
+### Bug Fixes
+
+  * Fix the tokenizer for good (#1)
+  * Fix the parser for empty lines and comments
+
+### Documentation
+
+  * Document the diff command
+
+## 1.0.0 / 2015-02-21
+
+### Minor Enhancements
+
+  * Initial release
+`
+
+func TestDiff(t *testing.T) {
+	diffs := Diff(parseMarkdown(t, diffOld), parseMarkdown(t, diffNew))
+
+	assert.Equal(t, `HEAD: + Add a merge driver (#11)
+HEAD, Bug Fixes: ~ Fix the tokenizer (#1) => Fix the tokenizer for good (#1)
+HEAD, Bug Fixes: ~ Fix the parser for empty lines => Fix the parser for empty lines and comments
+HEAD, Bug Fixes: - Remove the old renderer
+HEAD, Documentation: added
+HEAD, Documentation: + Document the diff command
+1.0.0: date changed from "2015-02-20" to "2015-02-21"`, diffs.String())
+	assert.Equal(t, DiffModified, diffs[1].Op)
+	assert.Equal(t, "Fix the tokenizer", diffs[1].Old.Summary)
+	assert.Equal(t, "Fix the tokenizer for good", diffs[1].New.Summary)
+}
+
+func TestDiff_VersionsAddedAndRemoved(t *testing.T) {
+	old := parseMarkdown(t, "# Changelog\n\n## HEAD\n\n  * Fix the tokenizer (#1)\n\n## 0.9.0\n\n  * Beta\n")
+	updated := parseMarkdown(t, "# History\n\n## 1.0.0 / 2015-02-20\n\n  * Fix the tokenizer (#1)\n\n## 0.9.0\n\n  * Beta\n")
+
+	diffs := Diff(old, updated)
+
+	assert.Equal(t, `preamble changed from "# Changelog" to "# History"
+1.0.0: added
+1.0.0: + Fix the tokenizer (#1)
+HEAD: removed
+HEAD: - Fix the tokenizer (#1)`, diffs.String())
+	assert.Len(t, diffs.InVersion("[Unreleased]"), 2)
+	assert.Len(t, diffs.InVersion("1.0.0"), 2)
+	assert.Empty(t, diffs.InVersion("0.9.0"))
+}
+
+func TestDiff_MatchesDialects(t *testing.T) {
+	history := parseKeepAChangelog(t)
+	converted := parseKeepAChangelog(t)
+	converted.SetDialect(Jekyll)
+	converted = parseMarkdown(t, converted.String())
+
+	assert.Empty(t, Diff(history, converted))
+	assert.Empty(t, Diff(history, history))
+}
+
+func TestDifferencesMarkdown(t *testing.T) {
+	diffs := Diff(parseMarkdown(t, diffOld), parseMarkdown(t, "# History\n\n"+diffNew[len("# Changelog\n\n"):]))
+
+	assert.Equal(t, `- Changed the preamble from "# Changelog" to "# History"
+
+## HEAD
+
+- Added: Add a merge driver (#11)
+
+### Bug Fixes
+
+- Changed: Fix the tokenizer (#1) → Fix the tokenizer for good (#1)
+- Changed: Fix the parser for empty lines → Fix the parser for empty lines and comments
+- Removed: Remove the old renderer
+
+### Documentation (added)
+
+- Added: Document the diff command
+
+## 1.0.0
+
+- Changed the date from "2015-02-20" to "2015-02-21"
+`, diffs.Markdown())
+	assert.Equal(t, "No changes.\n", Differences{}.Markdown())
+}
+
+func TestDifferencesJSON(t *testing.T) {
+	old := parseMarkdown(t, "## HEAD\n\n  * Fix the tokenizer (#1)\n")
+	updated := parseMarkdown(t, "## HEAD\n\n  * Fix the whole tokenizer (#1)\n\n## 1.0.0 / 2015-02-20\n")
+
+	data, err := json.Marshal(Diff(old, updated))
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[
+		{
+			"op": "modified",
+			"version": "HEAD",
+			"old": {"summary": "Fix the tokenizer", "reference": "#1", "references": [{"kind": "issue", "text": "#1", "id": "1"}]},
+			"new": {"summary": "Fix the whole tokenizer", "reference": "#1", "references": [{"kind": "issue", "text": "#1", "id": "1"}]}
+		},
+		{"op": "added", "version": "1.0.0"}
+	]`, string(data))
+}
+
+func TestChangeLineSimilarity(t *testing.T) {
+	line := &ChangeLine{Summary: "Fix the tokenizer", Reference: "#1"}
+	assert.Equal(t, 2.0, changeLineSimilarity(line, &ChangeLine{Summary: "Something else", Reference: "@parkr, #1"}))
+	assert.Equal(t, 0.0, changeLineSimilarity(line, &ChangeLine{Summary: "Something else", Reference: "parkr/changelog#1"}))
+	assert.Equal(t, 1.0, changeLineSimilarity(line, &ChangeLine{Summary: "fix the Tokenizer."}))
+	assert.InDelta(t, 2.0/3, changeLineSimilarity(line, &ChangeLine{Summary: "Fix the lexer"}), 0.001)
+	assert.Equal(t, 0.0, changeLineSimilarity(&ChangeLine{}, &ChangeLine{}))
+}
@@ -5,8 +5,8 @@
 // direct list of uncategorized changes, and can contain a set of
 // subsections. Subsections are a means of categorizing sets of changes
 // based on component or type of change. Each change consists of a summary
-// and a reference – either a pull request or issue number, or a @mention
-// to the contributing user.
+// and references – pull request or issue numbers, @mentions of the
+// contributing users, commit SHAs or links.
 //
 // A basic changelog might look something like:
 //
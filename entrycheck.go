@@ -0,0 +1,84 @@
+package changelog
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// EntryCheck decides whether a change to a git repository needs an entry
+// in the changelog, e.g. to require one for every pull request in CI. The
+// paths are relative to Root and slash-separated, like the ones listed by
+// ReadGitChangedFiles.
+type EntryCheck struct {
+	// Root is the root of the working copy, see FindGitRoot.
+	Root string
+	// Filename is the path of the changelog.
+	Filename string
+	// SkipFile is the path of a file which a change can add when it
+	// doesn't need an entry, e.g. ".changelog-skip". If empty, there is
+	// no such file.
+	SkipFile string
+	// Ignore lists the patterns of the files which don't need an entry
+	// when they change. "docs/" or "docs/**" match everything in a
+	// directory, patterns without a slash like "*.md" match the name of a
+	// file in any directory, and other patterns like "docs/*.md" match
+	// the whole path.
+	Ignore []string
+}
+
+// SkipReason returns why a change touching the changed files doesn't need
+// an entry in the changelog, or an empty string if it does.
+func (c *EntryCheck) SkipReason(changed []string) string {
+	filename := path.Clean(c.Filename)
+	skipFile := ""
+	if c.SkipFile != "" {
+		skipFile = path.Clean(c.SkipFile)
+	}
+	others := []string{}
+	for _, file := range changed {
+		switch {
+		case skipFile != "" && file == skipFile:
+			// A skip file which was deleted doesn't count.
+			if _, err := os.Stat(filepath.Join(c.Root, filepath.FromSlash(skipFile))); err == nil {
+				return "the change adds " + skipFile
+			}
+		case file != filename:
+			others = append(others, file)
+		}
+	}
+	if len(others) == 0 {
+		return "nothing else changed"
+	}
+	if len(c.Ignore) == 0 {
+		return ""
+	}
+	for _, file := range others {
+		ignored := false
+		for _, pattern := range c.Ignore {
+			if matchPath(pattern, file) {
+				ignored = true
+				break
+			}
+		}
+		if !ignored {
+			return ""
+		}
+	}
+	return "every changed file is ignored"
+}
+
+// matchPath checks whether the slash-separated path matches the pattern,
+// as described by EntryCheck.Ignore.
+func matchPath(pattern, name string) bool {
+	if strings.HasSuffix(pattern, "/") || strings.HasSuffix(pattern, "/**") {
+		dir := strings.TrimSuffix(strings.TrimSuffix(pattern, "**"), "/")
+		return strings.HasPrefix(name, dir+"/")
+	}
+	if !strings.Contains(pattern, "/") {
+		name = path.Base(name)
+	}
+	matched, _ := path.Match(pattern, name)
+	return matched
+}
@@ -0,0 +1,62 @@
+package changelog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchPath(t *testing.T) {
+	testCases := []struct {
+		pattern, name string
+		matches       bool
+	}{
+		{"docs/", "docs/index.md", true},
+		{"docs/", "docs/guides/setup.md", true},
+		{"docs/", "site/docs/index.md", false},
+		{"docs/", "docs.md", false},
+		{"docs/**", "docs/guides/setup.md", true},
+		{"*.md", "README.md", true},
+		{"*.md", "docs/guides/setup.md", true},
+		{"*.md", "main.go", false},
+		{"docs/*.md", "docs/index.md", true},
+		{"docs/*.md", "docs/guides/setup.md", false},
+		{"my docs/", "my docs/index.md", true},
+		{".github/workflows/*.yml", ".github/workflows/ci.yml", true},
+	}
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.matches, matchPath(testCase.pattern, testCase.name), "%q matching %q", testCase.pattern, testCase.name)
+	}
+}
+
+func TestEntryCheckSkipReason(t *testing.T) {
+	root, err := ioutil.TempDir("", "changelog")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, ".changelog-skip"), nil, 0644))
+
+	check := &EntryCheck{Root: root, Filename: "History.markdown", SkipFile: ".changelog-skip", Ignore: []string{"docs/", "*.txt"}}
+	testCases := []struct {
+		changed []string
+		reason  string
+	}{
+		{[]string{"main.go", "History.markdown"}, ""},
+		{[]string{"History.markdown"}, "nothing else changed"},
+		{[]string{}, "nothing else changed"},
+		{[]string{"main.go", ".changelog-skip"}, "the change adds .changelog-skip"},
+		{[]string{"docs/index.md", "notes.txt", "History.markdown"}, "every changed file is ignored"},
+		{[]string{"docs/index.md", "main.go"}, ""},
+	}
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.reason, check.SkipReason(testCase.changed), "%v", testCase.changed)
+	}
+
+	// A deleted skip file doesn't count.
+	check.SkipFile = "sub/.changelog-skip"
+	assert.Equal(t, "", check.SkipReason([]string{"main.go", "sub/.changelog-skip"}))
+	check = &EntryCheck{Root: root, Filename: "./sub/../History.markdown"}
+	assert.Equal(t, "nothing else changed", check.SkipReason([]string{"History.markdown"}))
+}
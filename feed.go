@@ -0,0 +1,231 @@
+package changelog
+
+import (
+	"encoding/xml"
+	"errors"
+	"strings"
+	"time"
+)
+
+// FeedConfig describes the feed of releases written by Atom and RSS.
+type FeedConfig struct {
+	// Title is the title of the feed. If empty, the first heading of the
+	// changelog's preamble is used, or "Changelog".
+	Title string
+	// Link is the URL of the page the changelog is published on. It is
+	// required. The entries of releases without a URL link to their
+	// section of the page, e.g. "https://example.com/changelog#v1.0.0".
+	Link string
+	// ID identifies an Atom feed. If empty, Link is used.
+	ID string
+	// Description describes the feed: the subtitle of an Atom feed, and
+	// the description of an RSS channel.
+	Description string
+	// Author is the name of the author of an Atom feed, which Atom
+	// requires. If empty, the title of the feed is used.
+	Author string
+	// Limit is the maximum number of entries. If 0, every release is
+	// listed.
+	Limit int
+	// Renderer renders the content of the entries. If nil, an
+	// HTMLRenderer without a Resolver is used. Its IDPrefix is also used
+	// for the links to the sections of the page.
+	Renderer *HTMLRenderer
+}
+
+// feedEntry is a release listed in a feed.
+type feedEntry struct {
+	title   string
+	link    string
+	date    time.Time
+	content string
+}
+
+// feedEntries returns the entries for the dated releases of the
+// changelog, in the order they are written. Releases whose date isn't
+// formatted like 2006-01-02 are left out, as are the unreleased changes.
+func (c *Changelog) feedEntries(config *FeedConfig) []*feedEntry {
+	renderer := config.Renderer
+	if renderer == nil {
+		renderer = &HTMLRenderer{}
+	}
+	c.sortVersions()
+	entries := []*feedEntry{}
+	for _, version := range c.Versions {
+		if config.Limit > 0 && len(entries) == config.Limit {
+			break
+		}
+		if version.Kind() != VersionKindRelease {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", version.Date)
+		if err != nil {
+			continue
+		}
+		entry := &feedEntry{
+			title:   strings.Trim(version.Version, "[]"),
+			link:    version.URL,
+			date:    date,
+			content: strings.Join(renderer.renderVersionBody(version), "\n"),
+		}
+		if version.Yanked {
+			entry.title += " [YANKED]"
+		}
+		if entry.link == "" {
+			entry.link = strings.SplitN(config.Link, "#", 2)[0] + "#" + renderer.IDPrefix + versionID(version)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// feedTitle returns the title of the feed.
+func (c *Changelog) feedTitle(config *FeedConfig) string {
+	if config.Title != "" {
+		return config.Title
+	}
+	return htmlTitle(c)
+}
+
+var errFeedLink = errors.New("a feed needs a link to the changelog")
+
+type atomFeed struct {
+	XMLName  xml.Name     `xml:"http://www.w3.org/2005/Atom feed"`
+	Title    string       `xml:"title"`
+	Subtitle string       `xml:"subtitle,omitempty"`
+	ID       string       `xml:"id"`
+	Updated  string       `xml:"updated"`
+	Link     atomLink     `xml:"link"`
+	Author   *atomAuthor  `xml:"author"`
+	Entries  []*atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Link    atomLink    `xml:"link"`
+	Content atomContent `xml:"content"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// Atom returns an Atom feed with an entry for each dated release, e.g. to
+// let users subscribe to them. The content of each entry is the HTML of
+// its changes. The feed was last updated on the date of its latest entry,
+// or at the Unix epoch if it has none.
+func (c *Changelog) Atom(config *FeedConfig) ([]byte, error) {
+	if config.Link == "" {
+		return nil, errFeedLink
+	}
+	feed := &atomFeed{
+		Title:    c.feedTitle(config),
+		Subtitle: config.Description,
+		ID:       config.ID,
+		Updated:  time.Unix(0, 0).UTC().Format(time.RFC3339),
+		Link:     atomLink{Href: config.Link},
+	}
+	if feed.ID == "" {
+		feed.ID = config.Link
+	}
+	feed.Author = &atomAuthor{Name: config.Author}
+	if feed.Author.Name == "" {
+		feed.Author.Name = feed.Title
+	}
+	var updated time.Time
+	for _, entry := range c.feedEntries(config) {
+		if entry.date.After(updated) {
+			updated = entry.date
+			feed.Updated = entry.date.Format(time.RFC3339)
+		}
+		feed.Entries = append(feed.Entries, &atomEntry{
+			Title:   entry.title,
+			ID:      entry.link,
+			Updated: entry.date.Format(time.RFC3339),
+			Link:    atomLink{Href: entry.link},
+			Content: atomContent{Type: "html", Body: entry.content},
+		})
+	}
+	return marshalFeed(feed)
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string     `xml:"title"`
+	Link          string     `xml:"link"`
+	Description   string     `xml:"description"`
+	LastBuildDate string     `xml:"lastBuildDate,omitempty"`
+	Items         []*rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+	Description string  `xml:"description"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// RSS returns an RSS 2.0 feed with an item for each dated release, like
+// Atom. If the config has no Description, the title of the feed is used.
+func (c *Changelog) RSS(config *FeedConfig) ([]byte, error) {
+	if config.Link == "" {
+		return nil, errFeedLink
+	}
+	feed := &rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:       c.feedTitle(config),
+			Link:        config.Link,
+			Description: config.Description,
+		},
+	}
+	if feed.Channel.Description == "" {
+		feed.Channel.Description = feed.Channel.Title
+	}
+	var updated time.Time
+	for _, entry := range c.feedEntries(config) {
+		if entry.date.After(updated) {
+			updated = entry.date
+			feed.Channel.LastBuildDate = entry.date.Format(time.RFC1123Z)
+		}
+		feed.Channel.Items = append(feed.Channel.Items, &rssItem{
+			Title:       entry.title,
+			Link:        entry.link,
+			GUID:        rssGUID{IsPermaLink: true, Value: entry.link},
+			PubDate:     entry.date.Format(time.RFC1123Z),
+			Description: entry.content,
+		})
+	}
+	return marshalFeed(feed)
+}
+
+// marshalFeed encodes the feed as an XML document.
+func marshalFeed(feed interface{}) ([]byte, error) {
+	data, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte(xml.Header), data...), '\n'), nil
+}
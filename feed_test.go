@@ -0,0 +1,129 @@
+package changelog
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func feedChangelog() *Changelog {
+	history := NewChangelog()
+	history.Preamble = "# Jekyll History"
+	history.GetVersionOrCreate("HEAD").History = []*ChangeLine{{Summary: "Unreleased change"}}
+	for _, version := range []struct{ version, date, url string }{
+		{"2.0.0", "2015-03-01", ""},
+		{"1.1.0", "someday", ""},
+		{"1.0.0", "2015-02-20", "https://github.com/jekyll/jekyll/releases/tag/v1.0.0"},
+		{"0.1.0", "", ""},
+	} {
+		v := history.GetVersionOrCreate(version.version)
+		v.Date = version.date
+		v.URL = version.url
+		v.History = []*ChangeLine{{Summary: "Release " + version.version, Reference: "#1"}}
+	}
+	history.GetVersion("1.0.0").Yanked = true
+	return history
+}
+
+func TestChangelogAtom(t *testing.T) {
+	resolver, err := NewResolver("https://github.com/jekyll/jekyll")
+	assert.NoError(t, err)
+
+	data, err := feedChangelog().Atom(&FeedConfig{
+		Link:     "https://jekyllrb.com/docs/history/",
+		Author:   "Jekyll",
+		Renderer: &HTMLRenderer{Resolver: resolver},
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Jekyll History</title>
+  <id>https://jekyllrb.com/docs/history/</id>
+  <updated>2015-03-01T00:00:00Z</updated>
+  <link href="https://jekyllrb.com/docs/history/"></link>
+  <author>
+    <name>Jekyll</name>
+  </author>
+  <entry>
+    <title>2.0.0</title>
+    <id>https://jekyllrb.com/docs/history/#v2.0.0</id>
+    <updated>2015-03-01T00:00:00Z</updated>`), string(data))
+
+	var feed atomFeed
+	assert.NoError(t, xml.Unmarshal(data, &feed))
+	assert.Len(t, feed.Entries, 2)
+	assert.Equal(t, "1.0.0 [YANKED]", feed.Entries[1].Title)
+	assert.Equal(t, "https://github.com/jekyll/jekyll/releases/tag/v1.0.0", feed.Entries[1].Link.Href)
+	assert.Equal(t, "2015-02-20T00:00:00Z", feed.Entries[1].Updated)
+	assert.Equal(t, "html", feed.Entries[1].Content.Type)
+	assert.Equal(t, `  <ul>
+    <li>Release 1.0.0 (<a href="https://github.com/jekyll/jekyll/issues/1">#1</a>)</li>
+  </ul>`, feed.Entries[1].Content.Body)
+}
+
+func TestChangelogAtom_Config(t *testing.T) {
+	data, err := feedChangelog().Atom(&FeedConfig{
+		Title:       "Releases",
+		Link:        "https://jekyllrb.com/docs/history/#top",
+		ID:          "tag:jekyllrb.com,2015:history",
+		Description: "Every release of Jekyll",
+		Limit:       1,
+		Renderer:    &HTMLRenderer{IDPrefix: "release-"},
+	})
+	assert.NoError(t, err)
+
+	var feed atomFeed
+	assert.NoError(t, xml.Unmarshal(data, &feed))
+	assert.Equal(t, "Releases", feed.Title)
+	assert.Equal(t, "Every release of Jekyll", feed.Subtitle)
+	assert.Equal(t, "tag:jekyllrb.com,2015:history", feed.ID)
+	assert.Equal(t, &atomAuthor{Name: "Releases"}, feed.Author, "Atom requires an author")
+	assert.Len(t, feed.Entries, 1)
+	assert.Equal(t, "https://jekyllrb.com/docs/history/#release-v2.0.0", feed.Entries[0].Link.Href)
+}
+
+func TestChangelogAtom_NoReleases(t *testing.T) {
+	data, err := NewChangelog().Atom(&FeedConfig{Link: "https://example.com"})
+	assert.NoError(t, err)
+
+	var feed atomFeed
+	assert.NoError(t, xml.Unmarshal(data, &feed))
+	assert.Equal(t, "Changelog", feed.Title)
+	assert.Equal(t, &atomAuthor{Name: "Changelog"}, feed.Author)
+	assert.Contains(t, string(data), "<author>\n    <name>Changelog</name>\n  </author>")
+	assert.Equal(t, "1970-01-01T00:00:00Z", feed.Updated)
+	assert.Empty(t, feed.Entries)
+}
+
+func TestChangelogRSS(t *testing.T) {
+	data, err := feedChangelog().RSS(&FeedConfig{Link: "https://jekyllrb.com/docs/history/"})
+
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+  <channel>
+    <title>Jekyll History</title>
+    <link>https://jekyllrb.com/docs/history/</link>
+    <description>Jekyll History</description>
+    <lastBuildDate>Sun, 01 Mar 2015 00:00:00 +0000</lastBuildDate>
+    <item>
+      <title>2.0.0</title>
+      <link>https://jekyllrb.com/docs/history/#v2.0.0</link>
+      <guid isPermaLink="true">https://jekyllrb.com/docs/history/#v2.0.0</guid>
+      <pubDate>Sun, 01 Mar 2015 00:00:00 +0000</pubDate>`), string(data))
+
+	var feed rssFeed
+	assert.NoError(t, xml.Unmarshal(data, &feed))
+	assert.Len(t, feed.Channel.Items, 2)
+	assert.Equal(t, "  <ul>\n    <li>Release 1.0.0 (#1)</li>\n  </ul>", feed.Channel.Items[1].Description)
+}
+
+func TestChangelogFeeds_NeedLink(t *testing.T) {
+	_, err := feedChangelog().Atom(&FeedConfig{})
+	assert.Error(t, err)
+	_, err = feedChangelog().RSS(&FeedConfig{})
+	assert.Error(t, err)
+}
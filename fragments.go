@@ -0,0 +1,274 @@
+package changelog
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// JekyllFragmentTypes maps the types of fragments to the subsections
+	// used by Jekyll.
+	JekyllFragmentTypes = map[string]string{
+		"major":   "Major Enhancements",
+		"feature": "Minor Enhancements",
+		"minor":   "Minor Enhancements",
+		"bugfix":  "Bug Fixes",
+		"doc":     "Documentation",
+		"dev":     "Development Fixes",
+		"site":    "Site Enhancements",
+	}
+
+	// KeepAChangelogFragmentTypes maps the types of fragments to the
+	// subsections of Keep a Changelog, including the types towncrier uses
+	// by default.
+	KeepAChangelogFragmentTypes = map[string]string{
+		"added":      Added,
+		"feature":    Added,
+		"changed":    Changed,
+		"deprecated": Deprecated,
+		"removed":    Removed,
+		"removal":    Removed,
+		"fixed":      Fixed,
+		"bugfix":     Fixed,
+		"security":   Security,
+	}
+)
+
+// FragmentTypes returns the default fragment types for the dialect.
+func FragmentTypes(d Dialect) map[string]string {
+	if d == KeepAChangelog {
+		return KeepAChangelogFragmentTypes
+	}
+	return JekyllFragmentTypes
+}
+
+// Fragment is a file holding changes for the changelog, so that pull
+// requests don't all edit the changelog itself. Fragments are named after
+// the issue or pull request they're for and their type, which decides
+// their subsection, e.g. "1234.bugfix.md". Several fragments for the same
+// issue are numbered, e.g. "1234.bugfix.1.md". Fragments without an issue
+// are named starting with a "+", e.g. "+tokenizer.bugfix.md".
+type Fragment struct {
+	// Path is the path to the fragment file.
+	Path string
+	// Reference is the reference derived from the name of the file, e.g.
+	// "#1234", or empty for fragments whose name starts with a "+".
+	Reference string
+	// Type is the type of the fragment, e.g. "bugfix".
+	Type string
+	// Text is the contents of the fragment: either a summary, or a list
+	// of changes.
+	Text string
+}
+
+// fragmentNameRegexp matches the name of a fragment file, which starts
+// with an issue number or a "+".
+var fragmentNameRegexp = regexp.MustCompile(`^(\d+|\+[^.\s]+)\.([\w-]+)(?:\.\d+)?\.(?:md|markdown)$`)
+
+// parseFragmentName parses the name of a fragment file, e.g.
+// "1234.bugfix.md", into its reference and type.
+func parseFragmentName(name string) (reference, fragmentType string, ok bool) {
+	matches := fragmentNameRegexp.FindStringSubmatch(name)
+	if matches == nil {
+		return "", "", false
+	}
+	if !strings.HasPrefix(matches[1], "+") {
+		reference = "#" + matches[1]
+	}
+	return reference, strings.ToLower(matches[2]), true
+}
+
+// fragmentName returns the name of the fragment file for the reference and
+// type. An empty reference gives a name starting with a "+", made from
+// the slug. The reference should be an issue, e.g. "#1234".
+func fragmentName(reference, fragmentType, slug string, n int) string {
+	name := strings.TrimPrefix(reference, "#")
+	if name == "" {
+		name = "+" + slug
+	}
+	name += "." + fragmentType
+	if n > 0 {
+		name += "." + strconv.Itoa(n)
+	}
+	return name + ".md"
+}
+
+// ReadFragments reads the fragment files in dir. Hidden files and README
+// files are skipped. The types are checked against the given fragment
+// types, which map them to subsections.
+func ReadFragments(dir string, types map[string]string) ([]*Fragment, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	fragments := []*Fragment{}
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || strings.HasPrefix(name, ".") || strings.HasPrefix(strings.ToUpper(name), "README") {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		reference, fragmentType, ok := parseFragmentName(name)
+		if !ok {
+			return nil, fmt.Errorf("%s: fragments should be named like 1234.bugfix.md", path)
+		}
+		if _, ok := types[fragmentType]; !ok {
+			return nil, fmt.Errorf("%s: unknown fragment type %q, should be one of %s", path, fragmentType, strings.Join(FragmentTypeNames(types), ", "))
+		}
+		text, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(string(text)) == "" {
+			return nil, fmt.Errorf("%s: fragment is empty", path)
+		}
+		fragments = append(fragments, &Fragment{
+			Path:      path,
+			Reference: reference,
+			Type:      fragmentType,
+			Text:      strings.TrimSpace(string(text)),
+		})
+	}
+	return fragments, nil
+}
+
+// NewFragment writes a fragment file in dir for the reference, e.g.
+// "#1234", of the given type, and returns its path. The reference can only
+// be an issue or empty, as it is read back from the name of the file. If there already is a
+// fragment for the reference and type, the new one is numbered. Fragments
+// without a reference are named after the first words of the text.
+func NewFragment(dir, reference, fragmentType, text string, types map[string]string) (string, error) {
+	if _, ok := types[fragmentType]; !ok {
+		return "", fmt.Errorf("unknown fragment type %q, should be one of %s", fragmentType, strings.Join(FragmentTypeNames(types), ", "))
+	}
+	if reference != "" && !issueRefRegexp.MatchString(reference) {
+		return "", fmt.Errorf("fragments can only be for an issue like #1234, not %q", reference)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	slug := slugify(text)
+	for n := 0; ; n++ {
+		path := filepath.Join(dir, fragmentName(reference, fragmentType, slug, n))
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if text != "" {
+			text = strings.TrimSpace(text) + "\n"
+		}
+		if _, err := file.WriteString(text); err != nil {
+			file.Close()
+			return "", err
+		}
+		return path, file.Close()
+	}
+}
+
+// slugRegexp matches the characters which are left out of a slug.
+var slugRegexp = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slugify turns the first few words of the text into a name for a file,
+// e.g. "fix-the-tokenizer".
+func slugify(text string) string {
+	words := strings.Fields(slugRegexp.ReplaceAllString(strings.ToLower(text), " "))
+	if len(words) > 5 {
+		words = words[:5]
+	}
+	if len(words) == 0 {
+		return "change"
+	}
+	return strings.Join(words, "-")
+}
+
+// ChangeLines returns the changes in the fragment. A fragment holding a
+// list has a change for each item, and any other fragment is a single
+// change. Each change gets the fragment's reference, unless it has its
+// own.
+func (f *Fragment) ChangeLines() []*ChangeLine {
+	items := []string{}
+	for _, txt := range strings.Split(f.Text, "\n") {
+		trimmed := strings.TrimSpace(txt)
+		switch {
+		case trimmed == "":
+			continue
+		case isChangeLine(txt):
+			items = append(items, strings.TrimSpace(trimmed[1:]))
+		case len(items) == 0:
+			items = append(items, trimmed)
+		default:
+			items[len(items)-1] += " " + trimmed
+		}
+	}
+
+	lines := make([]*ChangeLine, len(items))
+	for i, item := range items {
+		lines[i] = newChangeLine(item)
+		if lines[i].Reference == "" {
+			lines[i].Reference = f.Reference
+		}
+	}
+	return lines
+}
+
+// CollectFragments adds the changes in the fragments to the subsections of
+// the unreleased changes, using AddLineToSubsection. The types map the
+// fragments' types to subsections; if nil, the fragment types of the
+// changelog's Dialect are used. Changes which are already listed are
+// skipped. Returns the changes which were added.
+func (c *Changelog) CollectFragments(fragments []*Fragment, types map[string]string) []*ChangeLine {
+	if types == nil {
+		types = FragmentTypes(c.dialect())
+	}
+	versionNum := c.dialect().Unreleased()
+	if unreleased := c.GetUnreleased(); unreleased != nil {
+		versionNum = unreleased.Version
+	}
+
+	// Add the changes in the order of their issues.
+	sorted := append([]*Fragment{}, fragments...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := strings.TrimPrefix(sorted[i].Reference, "#"), strings.TrimPrefix(sorted[j].Reference, "#")
+		aNum, aErr := strconv.Atoi(a)
+		bNum, bErr := strconv.Atoi(b)
+		if aErr == nil && bErr == nil {
+			return aNum < bNum
+		}
+		return aErr == nil && bErr != nil
+	})
+
+	added := []*ChangeLine{}
+	for _, fragment := range sorted {
+		subsection, ok := types[fragment.Type]
+		if !ok {
+			continue
+		}
+		for _, line := range fragment.ChangeLines() {
+			if c.HasLine(versionNum, subsection, line) {
+				continue
+			}
+			c.AddLineToSubsection(versionNum, subsection, line)
+			added = append(added, line)
+		}
+	}
+	return added
+}
+
+// FragmentTypeNames returns the names of the fragment types, in order.
+func FragmentTypeNames(types map[string]string) []string {
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
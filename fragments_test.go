@@ -0,0 +1,122 @@
+package changelog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFragmentName(t *testing.T) {
+	testCases := []struct {
+		name, reference, fragmentType string
+	}{
+		{"1234.bugfix.md", "#1234", "bugfix"},
+		{"1234.Bugfix.2.markdown", "#1234", "bugfix"},
+		{"+fix-the-tokenizer.bugfix.md", "", "bugfix"},
+	}
+	for _, testCase := range testCases {
+		reference, fragmentType, ok := parseFragmentName(testCase.name)
+		assert.True(t, ok, testCase.name)
+		assert.Equal(t, testCase.reference, reference, testCase.name)
+		assert.Equal(t, testCase.fragmentType, fragmentType, testCase.name)
+	}
+
+	for _, name := range []string{"1234.md", "1234.bugfix.txt", "bugfix", "@parkr.doc.md", "tokenizer.bugfix.md", "parkr#12.bugfix.md"} {
+		_, _, ok := parseFragmentName(name)
+		assert.False(t, ok, name)
+	}
+}
+
+func TestReadFragments(t *testing.T) {
+	fragments, err := ReadFragments("testdata/changes", JekyllFragmentTypes)
+	assert.NoError(t, err)
+	assert.Equal(t, []*Fragment{
+		{Path: filepath.Join("testdata/changes", "+fragments.doc.md"), Type: "doc", Text: "Explain fragments"},
+		{Path: filepath.Join("testdata/changes", "1200.feature.md"), Reference: "#1200", Type: "feature", Text: "- Add a linter\n- Add a `lint` command (@parkr)"},
+		{Path: filepath.Join("testdata/changes", "1234.bugfix.1.md"), Reference: "#1234", Type: "bugfix", Text: "Fix the parser"},
+		{Path: filepath.Join("testdata/changes", "1234.bugfix.md"), Reference: "#1234", Type: "bugfix", Text: "Fix the tokenizer\nwhen it sees tabs."},
+	}, fragments)
+
+	_, err = ReadFragments("testdata/changes", KeepAChangelogFragmentTypes)
+	assert.EqualError(t, err, `testdata/changes/+fragments.doc.md: unknown fragment type "doc", should be one of added, bugfix, changed, deprecated, feature, fixed, removal, removed, security`)
+}
+
+func TestFragmentChangeLines(t *testing.T) {
+	fragment := &Fragment{Reference: "#1200", Text: "- Add a linter\n  for changelogs\n- Add a `lint` command (@parkr)"}
+	assert.Equal(t, []*ChangeLine{
+		{Summary: "Add a linter for changelogs", Reference: "#1200"},
+		{Summary: "Add a `lint` command", Reference: "@parkr"},
+	}, fragment.ChangeLines())
+
+	fragment = &Fragment{Text: "Fix the tokenizer\nwhen it sees tabs."}
+	assert.Equal(t, []*ChangeLine{{Summary: "Fix the tokenizer when it sees tabs."}}, fragment.ChangeLines())
+
+	fragment = &Fragment{Reference: "#1300", Text: "**Breaking**: drop Ruby 1.9\n- --verbose prints more"}
+	assert.Equal(t, []*ChangeLine{
+		{Summary: "**Breaking**: drop Ruby 1.9", Reference: "#1300"},
+		{Summary: "--verbose prints more", Reference: "#1300"},
+	}, fragment.ChangeLines())
+}
+
+func TestCollectFragments(t *testing.T) {
+	fragments, err := ReadFragments("testdata/changes", JekyllFragmentTypes)
+	assert.NoError(t, err)
+	history := NewChangelog()
+	history.AddLineToSubsection("HEAD", "Bug Fixes", &ChangeLine{Summary: "Fix the parser", Reference: "#1234"})
+
+	added := history.CollectFragments(fragments, nil)
+
+	assert.Len(t, added, 4)
+	assert.Equal(t, `## HEAD
+
+### Bug Fixes
+
+  * Fix the parser (#1234)
+  * Fix the tokenizer when it sees tabs. (#1234)
+
+### Minor Enhancements
+
+  * Add a linter (#1200)
+  * Add a `+"`lint`"+` command (@parkr)
+
+### Documentation
+
+  * Explain fragments
+`, history.String())
+}
+
+func TestNewFragment(t *testing.T) {
+	dir, err := ioutil.TempDir("", "changelog-fragments")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	dir = filepath.Join(dir, "changes")
+
+	path, err := NewFragment(dir, "#1234", "bugfix", "Fix the tokenizer", JekyllFragmentTypes)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "1234.bugfix.md"), path)
+	contents, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "Fix the tokenizer\n", string(contents))
+
+	path, err = NewFragment(dir, "#1234", "bugfix", "Fix the parser", JekyllFragmentTypes)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "1234.bugfix.1.md"), path)
+
+	path, err = NewFragment(dir, "", "doc", "Explain fragments, at last!", JekyllFragmentTypes)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "+explain-fragments-at-last.doc.md"), path)
+
+	fragments, err := ReadFragments(dir, JekyllFragmentTypes)
+	assert.NoError(t, err)
+	assert.Len(t, fragments, 3)
+
+	_, err = NewFragment(dir, "#1", "bugs", "", JekyllFragmentTypes)
+	assert.EqualError(t, err, `unknown fragment type "bugs", should be one of bugfix, dev, doc, feature, major, minor, site`)
+	for _, reference := range []string{"parkr/changelog#12", "@parkr", "#12a"} {
+		_, err = NewFragment(dir, reference, "bugfix", "Fix the tokenizer", JekyllFragmentTypes)
+		assert.EqualError(t, err, `fragments can only be for an issue like #1234, not "`+reference+`"`)
+	}
+}
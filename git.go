@@ -0,0 +1,296 @@
+package changelog
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Commit is a commit read from a git repository.
+type Commit struct {
+	SHA     string
+	Author  string
+	Subject string
+	Body    string
+	// Parents is the number of parents, which is more than 1 for merge
+	// commits.
+	Parents int
+}
+
+// The separators between the fields and the commits in the output of git
+// log, which can't appear in commit messages.
+const (
+	gitFieldSeparator  = "\x1f"
+	gitCommitSeparator = "\x1e"
+)
+
+// checkGitRevision checks that the revision can be passed to git as an
+// argument, rather than being taken for an option like "--output=FILE".
+func checkGitRevision(rev string) error {
+	if rev == "" || strings.HasPrefix(rev, "-") {
+		return fmt.Errorf("invalid revision %q", rev)
+	}
+	return nil
+}
+
+// ReadGitLog reads the commits of the current branch of the git repository
+// in dir, newest first, using the git binary. If since isn't empty, only
+// the commits made after that revision, e.g. "v4.1.0", are read.
+func ReadGitLog(dir, since string) ([]*Commit, error) {
+	revisions := "HEAD"
+	if since != "" {
+		if err := checkGitRevision(since); err != nil {
+			return nil, err
+		}
+		revisions = since + "..HEAD"
+	}
+	format := strings.Join([]string{"%H", "%an", "%P", "%s", "%b"}, gitFieldSeparator) + gitCommitSeparator
+	output, err := runGit(dir, "log", "--format="+format, revisions, "--")
+	if err != nil {
+		return nil, err
+	}
+
+	commits := []*Commit{}
+	for _, record := range strings.Split(output, gitCommitSeparator) {
+		fields := strings.Split(strings.TrimLeft(record, "\n"), gitFieldSeparator)
+		if len(fields) != 5 {
+			continue
+		}
+		commits = append(commits, &Commit{
+			SHA:     fields[0],
+			Author:  fields[1],
+			Parents: len(strings.Fields(fields[2])),
+			Subject: fields[3],
+			Body:    strings.TrimSpace(fields[4]),
+		})
+	}
+	return commits, nil
+}
+
+// FindGitTag finds the tag for the version in the git repository in dir,
+// e.g. "v4.1.0" or "4.1.0" for version 4.1.0. Returns an empty string if
+// there is no such tag.
+func FindGitTag(dir, versionNum string) string {
+	versionNum = strings.Trim(versionNum, "[]")
+	for _, tag := range []string{versionNum, "v" + strings.TrimPrefix(versionNum, "v")} {
+		if _, err := runGit(dir, "rev-parse", "--verify", "--quiet", "refs/tags/"+tag); err == nil {
+			return tag
+		}
+	}
+	return ""
+}
+
+// FindGitMergeBase finds the commit where the current branch of the git
+// repository in dir and the given revision diverged, e.g. where a branch
+// was created from "origin/main".
+func FindGitMergeBase(dir, rev string) (string, error) {
+	if err := checkGitRevision(rev); err != nil {
+		return "", err
+	}
+	output, err := runGit(dir, "merge-base", rev, "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// FindGitRoot finds the root of the working copy of the git repository
+// which dir is in.
+func FindGitRoot(dir string) (string, error) {
+	output, err := runGit(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(output, "\n"), nil
+}
+
+// ReadGitChangedFiles lists the files which differ between the given
+// revision and the working copy of the git repository in dir, including
+// new files which aren't tracked yet. The paths are slash-separated and
+// relative to the root of the repository, even if dir is a subdirectory.
+func ReadGitChangedFiles(dir, rev string) ([]string, error) {
+	if err := checkGitRevision(rev); err != nil {
+		return nil, err
+	}
+	root, err := FindGitRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	changed, err := runGit(root, "diff", "--name-only", "-z", "--no-renames", rev, "--")
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := runGit(root, "ls-files", "-z", "--others", "--exclude-standard", "--full-name")
+	if err != nil {
+		return nil, err
+	}
+	files := []string{}
+	for _, file := range strings.Split(changed+untracked, "\x00") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// ReadGitChangelog parses the changelog in the file as it is at the given
+// revision of the git repository in dir. The filename is relative to dir.
+// If the file doesn't exist at that revision, the error wraps
+// os.ErrNotExist.
+func ReadGitChangelog(dir, rev, filename string) (*Changelog, error) {
+	if err := checkGitRevision(rev); err != nil {
+		return nil, err
+	}
+	object := rev + ":./" + filepath.ToSlash(filepath.Clean(filename))
+	if _, err := runGit(dir, "cat-file", "-e", object); err != nil {
+		if _, err := runGit(dir, "rev-parse", "--verify", "--quiet", rev+"^{commit}"); err != nil {
+			return nil, fmt.Errorf("unknown revision %s", rev)
+		}
+		return nil, fmt.Errorf("%s doesn't exist at %s: %w", filename, rev, os.ErrNotExist)
+	}
+	contents, err := runGit(dir, "show", object)
+	if err != nil {
+		return nil, err
+	}
+	return NewChangelogFromReader(strings.NewReader(contents))
+}
+
+// runGit runs git with the given arguments in dir, and returns its output.
+func runGit(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], message)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
+
+var (
+	// squashSubjectRegexp matches the subject of a commit which was
+	// squashed or rebased from a pull request, e.g.
+	// "Fix the tokenizer (#123)".
+	squashSubjectRegexp = regexp.MustCompile(`^(.+?)\s+\(#(\d+)\)$`)
+	// githubMergeRegexp matches the subject of a pull request's merge
+	// commit on GitHub, e.g. "Merge pull request #123 from parkr/fix".
+	githubMergeRegexp = regexp.MustCompile(`^Merge pull request #(\d+) from (\S+)`)
+	// gitlabMergeRegexp matches the last line of a merge request's merge
+	// commit on GitLab, e.g. "See merge request parkr/changelog!12".
+	gitlabMergeRegexp = regexp.MustCompile(`(?m)^See merge request \S+!(\d+)$`)
+)
+
+// ChangeLine builds the ChangeLine for the commit. Pull requests are
+// referenced by their number, e.g. "#123", and other commits by their
+// abbreviated SHA. Returns nil for merge commits which aren't for a pull
+// request, like "Merge branch 'main'".
+func (commit *Commit) ChangeLine() *ChangeLine {
+	if matches := githubMergeRegexp.FindStringSubmatch(commit.Subject); matches != nil {
+		summary := firstLine(commit.Body)
+		if summary == "" {
+			summary = matches[2]
+		}
+		return &ChangeLine{Summary: summary, Reference: "#" + matches[1]}
+	}
+	if matches := gitlabMergeRegexp.FindStringSubmatch(commit.Body); matches != nil {
+		summary := firstLine(commit.Body)
+		if strings.HasPrefix(summary, "See merge request ") {
+			summary = commit.Subject
+		}
+		return &ChangeLine{Summary: summary, Reference: "!" + matches[1]}
+	}
+	if commit.Parents > 1 {
+		return nil
+	}
+	if matches := squashSubjectRegexp.FindStringSubmatch(commit.Subject); matches != nil {
+		return &ChangeLine{Summary: matches[1], Reference: "#" + matches[2]}
+	}
+	sha := commit.SHA
+	if len(sha) > 7 {
+		sha = sha[:7]
+	}
+	return &ChangeLine{Summary: commit.Subject, Reference: sha}
+}
+
+// references lists the issues, pull requests and commits referenced
+// anywhere in the changelog, e.g. "#123", "!12" or a SHA.
+func (c *Changelog) references() (issues map[string]bool, commits []string) {
+	issues = map[string]bool{}
+	addAll := func(lines []*ChangeLine) {
+		for _, line := range lines {
+			for _, ref := range line.References() {
+				switch ref.Kind {
+				case ReferenceIssue:
+					issues["#"+ref.ID] = true
+				case ReferencePullRequest:
+					issues["!"+ref.ID] = true
+				case ReferenceCommit:
+					commits = append(commits, ref.ID)
+				}
+			}
+		}
+	}
+	for _, version := range c.Versions {
+		addAll(version.History)
+		for _, subsection := range version.Subsections {
+			addAll(subsection.History)
+		}
+	}
+	return issues, commits
+}
+
+// AddCommits adds a change for each of the commits to the unreleased
+// changes, oldest first, using AddLineToVersion. Commits which are already
+// referenced in the changelog, by their pull request or their SHA, are
+// skipped, as are merge commits which aren't for a pull request. Returns
+// the changes which were added.
+func (c *Changelog) AddCommits(commits []*Commit) []*ChangeLine {
+	return c.addCommits(commits, func(versionNum string, commit *Commit, line *ChangeLine) bool {
+		c.AddLineToVersion(versionNum, line)
+		return true
+	})
+}
+
+// addCommits calls add with the change for each of the commits which isn't
+// in the changelog yet, oldest first, and returns the changes it added.
+func (c *Changelog) addCommits(commits []*Commit, add func(versionNum string, commit *Commit, line *ChangeLine) bool) []*ChangeLine {
+	versionNum := c.dialect().Unreleased()
+	if unreleased := c.GetUnreleased(); unreleased != nil {
+		versionNum = unreleased.Version
+	}
+	issues, shas := c.references()
+
+	added := []*ChangeLine{}
+	for i := len(commits) - 1; i >= 0; i-- {
+		commit := commits[i]
+		line := commit.ChangeLine()
+		if line == nil || issues[line.Reference] || isReferencedCommit(commit.SHA, shas) {
+			continue
+		}
+		if !add(versionNum, commit, line) {
+			continue
+		}
+		added = append(added, line)
+		issues[line.Reference] = true
+		shas = append(shas, commit.SHA)
+	}
+	return added
+}
+
+// isReferencedCommit checks whether the SHA, or an abbreviation of it, is
+// in the list.
+func isReferencedCommit(sha string, shas []string) bool {
+	for _, referenced := range shas {
+		if strings.HasPrefix(sha, referenced) {
+			return true
+		}
+	}
+	return false
+}
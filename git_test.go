@@ -0,0 +1,203 @@
+package changelog
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommitChangeLine(t *testing.T) {
+	testCases := []struct {
+		commit   *Commit
+		expected *ChangeLine
+	}{
+		{
+			&Commit{SHA: "0f4477a1b2c3", Subject: "Add basic support for JRuby", Parents: 1},
+			&ChangeLine{Summary: "Add basic support for JRuby", Reference: "0f4477a"},
+		},
+		{
+			&Commit{SHA: "0f4477a1b2c3", Subject: "Fix the tokenizer (#123)", Parents: 1},
+			&ChangeLine{Summary: "Fix the tokenizer", Reference: "#123"},
+		},
+		{
+			&Commit{SHA: "0f4477a1b2c3", Subject: "Merge pull request #123 from parkr/fix-tokenizer", Body: "Fix the tokenizer\n\nIt was broken.", Parents: 2},
+			&ChangeLine{Summary: "Fix the tokenizer", Reference: "#123"},
+		},
+		{
+			&Commit{SHA: "0f4477a1b2c3", Subject: "Merge pull request #123 from parkr/fix-tokenizer", Parents: 2},
+			&ChangeLine{Summary: "parkr/fix-tokenizer", Reference: "#123"},
+		},
+		{
+			&Commit{SHA: "0f4477a1b2c3", Subject: "Merge branch 'fix-tokenizer' into 'main'", Body: "Fix the tokenizer\n\nSee merge request parkr/changelog!12", Parents: 2},
+			&ChangeLine{Summary: "Fix the tokenizer", Reference: "!12"},
+		},
+		{
+			&Commit{SHA: "0f4477a1b2c3", Subject: "Merge branch 'main' into fix-tokenizer", Parents: 2},
+			nil,
+		},
+	}
+	for _, testCase := range testCases {
+		assert.Equal(t, testCase.expected, testCase.commit.ChangeLine(), testCase.commit.Subject)
+	}
+}
+
+func TestChangelogAddCommits(t *testing.T) {
+	history, err := NewChangelogFromReader(strings.NewReader(`## [Unreleased]
+
+### Fixed
+
+- Fix the tokenizer (#123)
+
+## [1.0.0] - 2015-02-20
+
+- Initial release (0f4477a)
+`))
+	assert.NoError(t, err)
+
+	// Newest first, like git log.
+	added := history.AddCommits([]*Commit{
+		{SHA: "d00d1e5", Subject: "Add a linter (#125)", Parents: 1},
+		{SHA: "c0ffee1", Subject: "Merge pull request #123 from parkr/fix-tokenizer", Body: "Fix the tokenizer", Parents: 2},
+		{SHA: "bead1e5", Subject: "Add a parser (#124)", Parents: 1},
+		{SHA: "0f4477a1b2c3", Subject: "Initial release", Parents: 1},
+	})
+
+	assert.Equal(t, []*ChangeLine{
+		{Summary: "Add a parser", Reference: "#124"},
+		{Summary: "Add a linter", Reference: "#125"},
+	}, added)
+	assert.Equal(t, added, history.GetVersion("[Unreleased]").History)
+	assert.Len(t, history.Versions, 2)
+}
+
+// gitRepo creates a git repository with the given commit subjects, oldest
+// first, and tags the first commit "v1.0.0".
+func gitRepo(t *testing.T, subjects ...string) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir, err := ioutil.TempDir("", "changelog-git")
+	if err != nil {
+		t.Fatal(err)
+	}
+	git := func(args ...string) {
+		testGit(t, dir, args...)
+	}
+	git("init", "-q")
+	for i, subject := range subjects {
+		git("commit", "-q", "--allow-empty", "-m", subject)
+		if i == 0 {
+			git("tag", "v1.0.0")
+		}
+	}
+	return dir
+}
+
+// testGit runs git in dir, failing the test if it fails.
+func testGit(t *testing.T, dir string, args ...string) {
+	cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=Parker", "-c", "user.email=parker@example.com", "-c", "commit.gpgsign=false", "-c", "tag.gpgsign=false"}, args...)...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %s", strings.Join(args, " "), output)
+	}
+}
+
+func TestReadGitLog(t *testing.T) {
+	dir := gitRepo(t, "Initial release", "Add a parser (#124)", "Fix the tokenizer\n\nIt was broken.")
+	defer os.RemoveAll(dir)
+
+	commits, err := ReadGitLog(dir, "")
+	assert.NoError(t, err)
+	assert.Len(t, commits, 3)
+
+	commits, err = ReadGitLog(dir, "v1.0.0")
+	assert.NoError(t, err)
+	assert.Len(t, commits, 2)
+	assert.Equal(t, "Fix the tokenizer", commits[0].Subject)
+	assert.Equal(t, "It was broken.", commits[0].Body)
+	assert.Equal(t, "Parker", commits[0].Author)
+	assert.Equal(t, 1, commits[0].Parents)
+	assert.Len(t, commits[0].SHA, 40)
+	assert.Equal(t, "Add a parser (#124)", commits[1].Subject)
+
+	_, err = ReadGitLog(dir, "v9.9.9")
+	assert.Error(t, err)
+	_, err = ReadGitLog(dir, "--output=/tmp/changelog-git-log")
+	assert.EqualError(t, err, `invalid revision "--output=/tmp/changelog-git-log"`)
+}
+
+func TestFindGitTag(t *testing.T) {
+	dir := gitRepo(t, "Initial release")
+	defer os.RemoveAll(dir)
+
+	assert.Equal(t, "v1.0.0", FindGitTag(dir, "1.0.0"))
+	assert.Equal(t, "v1.0.0", FindGitTag(dir, "[v1.0.0]"))
+	assert.Equal(t, "", FindGitTag(dir, "2.0.0"))
+}
+
+func TestReadGitChangelog(t *testing.T) {
+	dir := gitRepo(t, "Initial release")
+	defer os.RemoveAll(dir)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "History.markdown"), []byte("## HEAD\n\n  * Fix the tokenizer (#1)\n"), 0644))
+	testGit(t, dir, "add", "History.markdown")
+	testGit(t, dir, "commit", "-q", "-m", "Add a changelog")
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "History.markdown"), []byte("## HEAD\n\n  * Changed\n"), 0644))
+
+	history, err := ReadGitChangelog(dir, "HEAD", "History.markdown")
+	assert.NoError(t, err)
+	assert.Equal(t, "## HEAD\n\n  * Fix the tokenizer (#1)\n", history.String())
+
+	_, err = ReadGitChangelog(dir, "v1.0.0", "History.markdown")
+	assert.True(t, errors.Is(err, os.ErrNotExist), "%v", err)
+	_, err = ReadGitChangelog(dir, "v9.9.9", "History.markdown")
+	assert.EqualError(t, err, "unknown revision v9.9.9")
+}
+
+func TestFindGitRoot(t *testing.T) {
+	dir := gitRepo(t, "Initial release")
+	defer os.RemoveAll(dir)
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "docs"), 0755))
+
+	root, err := FindGitRoot(filepath.Join(dir, "docs"))
+	assert.NoError(t, err)
+	expected, err := filepath.EvalSymlinks(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, root)
+}
+
+func TestReadGitChangedFiles(t *testing.T) {
+	dir := gitRepo(t, "Initial release")
+	defer os.RemoveAll(dir)
+	testGit(t, dir, "checkout", "-q", "-b", "feature")
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "docs"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "docs", "index.md"), []byte("# Docs\n"), 0644))
+	testGit(t, dir, "add", "docs")
+	testGit(t, dir, "commit", "-q", "-m", "Add docs")
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte("# README\n"), 0644))
+
+	base, err := FindGitMergeBase(dir, "v1.0.0")
+	assert.NoError(t, err)
+	assert.Len(t, base, 40)
+
+	files, err := ReadGitChangedFiles(dir, base)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"docs/index.md", "README.md"}, files)
+
+	// The paths are relative to the root, and can have spaces in them.
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "docs", "getting started.md"), []byte("# Start\n"), 0644))
+	files, err = ReadGitChangedFiles(filepath.Join(dir, "docs"), base)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"docs/index.md", "README.md", "docs/getting started.md"}, files)
+
+	_, err = FindGitMergeBase(dir, "v9.9.9")
+	assert.Error(t, err)
+	_, err = FindGitMergeBase(dir, "--all")
+	assert.EqualError(t, err, `invalid revision "--all"`)
+	_, err = ReadGitChangedFiles(dir, "--output=/tmp/changelog-git-diff")
+	assert.EqualError(t, err, `invalid revision "--output=/tmp/changelog-git-diff"`)
+}
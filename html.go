@@ -0,0 +1,400 @@
+package changelog
+
+import (
+	"html"
+	"html/template"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTMLRenderer writes changelogs as semantic HTML, e.g. for the release
+// notes on a website. Each version is a <section> with an id, so that it
+// can be linked to, e.g. "#v1.0.0" or "#unreleased":
+//
+//	<section id="v1.0.0" class="version">
+//	  <h2>1.0.0 <time datetime="2015-02-20">2015-02-20</time></h2>
+//	  <section class="subsection">
+//	    <h3>Bug Fixes</h3>
+//	    <ul>
+//	      <li>Fix the <code>tokenizer</code> (<a href="https://github.com/parkr/changelog/issues/123">#123</a>)</li>
+//	    </ul>
+//	  </section>
+//	</section>
+//
+// The inline markdown of summaries, code spans, links and emphasis, is
+// rendered and everything else is escaped. Links are only kept if they are
+// relative or use http, https or mailto. Prose like the preamble is
+// rendered as headings and paragraphs.
+type HTMLRenderer struct {
+	// Resolver links the references of the changes. If nil, only the
+	// references which are URLs are linked.
+	Resolver Resolver
+	// IDPrefix goes before the id of every version, to keep them from
+	// clashing with the other ids of a page.
+	IDPrefix string
+}
+
+// HTML returns the changelog as HTML, rendered by an HTMLRenderer without
+// a Resolver.
+func (c *Changelog) HTML() string {
+	return (&HTMLRenderer{}).Render(c)
+}
+
+// Render returns the HTML for the changelog.
+func (r *HTMLRenderer) Render(c *Changelog) string {
+	c.sortVersions()
+	blocks := []string{}
+	if c.Preamble != "" {
+		blocks = append(blocks, renderHTMLProse(c.Preamble, ""))
+	}
+	for _, version := range c.Versions {
+		blocks = append(blocks, r.RenderVersion(version))
+	}
+	if c.Epilogue != "" {
+		blocks = append(blocks, renderHTMLProse(c.Epilogue, ""))
+	}
+	return strings.Join(blocks, "\n") + "\n"
+}
+
+// RenderVersion returns the HTML for the version. The changes without a
+// version header are rendered without a heading.
+func (r *HTMLRenderer) RenderVersion(v *Version) string {
+	lines := []string{}
+	if v.Kind() == VersionKindUnversioned {
+		lines = append(lines, `<section class="version">`)
+	} else {
+		lines = append(lines, `<section id="`+html.EscapeString(r.IDPrefix+versionID(v))+`" class="version">`)
+		lines = append(lines, "  <h2>"+versionHeadingHTML(v)+"</h2>")
+	}
+	lines = append(lines, r.renderVersionBody(v)...)
+	lines = append(lines, "</section>")
+	return strings.Join(lines, "\n")
+}
+
+// renderVersionBody returns the HTML for everything under the version's
+// heading.
+func (r *HTMLRenderer) renderVersionBody(v *Version) []string {
+	lines := []string{}
+	if v.Description != "" {
+		lines = append(lines, renderHTMLProse(v.Description, "  "))
+	}
+	if len(v.History) > 0 {
+		lines = append(lines, r.renderChangeLines(v.History, "  "))
+	}
+	for _, subsection := range v.Subsections {
+		lines = append(lines, r.renderSubsection(subsection))
+	}
+	return lines
+}
+
+func (r *HTMLRenderer) renderSubsection(s *Subsection) string {
+	lines := []string{
+		`  <section class="subsection">`,
+		"    <h3>" + html.EscapeString(s.Name) + "</h3>",
+	}
+	if s.Description != "" {
+		lines = append(lines, renderHTMLProse(s.Description, "    "))
+	}
+	if len(s.History) > 0 {
+		lines = append(lines, r.renderChangeLines(s.History, "    "))
+	}
+	lines = append(lines, "  </section>")
+	return strings.Join(lines, "\n")
+}
+
+func (r *HTMLRenderer) renderChangeLines(changes []*ChangeLine, indent string) string {
+	lines := []string{indent + "<ul>"}
+	for _, change := range changes {
+		var b strings.Builder
+		renderInlineMarkdown(&b, change.Summary)
+		if change.Reference != "" {
+			b.WriteString(" (")
+			r.renderReferences(&b, change.Reference)
+			b.WriteString(")")
+		}
+		lines = append(lines, indent+"  <li>"+b.String()+"</li>")
+	}
+	lines = append(lines, indent+"</ul>")
+	return strings.Join(lines, "\n")
+}
+
+// renderReferences writes the list of references, linking each one the
+// resolver knows the URL of.
+func (r *HTMLRenderer) renderReferences(b *strings.Builder, list string) {
+	last := 0
+	for _, match := range referenceTokenRegexp.FindAllStringIndex(list, -1) {
+		b.WriteString(html.EscapeString(list[last:match[0]]))
+		text := list[match[0]:match[1]]
+		ref := parseReference(text)
+		url := ""
+		if label, linked, ok := markdownLink(text); ok {
+			text, url = label, linked
+		} else if r.Resolver != nil {
+			url = r.Resolver.URL(ref)
+		} else if ref.Kind == ReferenceURL {
+			url = ref.ID
+		}
+		if url != "" && isSafeURL(url) {
+			b.WriteString(`<a href="` + html.EscapeString(url) + `">` + html.EscapeString(text) + "</a>")
+		} else {
+			b.WriteString(html.EscapeString(text))
+		}
+		last = match[1]
+	}
+	b.WriteString(html.EscapeString(list[last:]))
+}
+
+var htmlIDRegexp = regexp.MustCompile(`[^a-z0-9._]+`)
+
+// versionID returns the id of the version's section, e.g. "v1.0.0" or
+// "unreleased".
+func versionID(v *Version) string {
+	if v.Kind() == VersionKindUnreleased {
+		return "unreleased"
+	}
+	id := strings.Trim(htmlIDRegexp.ReplaceAllString(strings.ToLower(v.Version), "-"), "-")
+	if id == "" || id[0] >= '0' && id[0] <= '9' {
+		id = "v" + id
+	}
+	return id
+}
+
+// versionHeadingHTML returns the contents of the version's heading: the
+// version, linked to its URL if it has one, and its date.
+func versionHeadingHTML(v *Version) string {
+	title := html.EscapeString(strings.Trim(v.Version, "[]"))
+	if v.Kind() == VersionKindUnreleased {
+		title = "Unreleased"
+	}
+	if v.URL != "" && isSafeURL(v.URL) {
+		title = `<a href="` + html.EscapeString(v.URL) + `">` + title + "</a>"
+	}
+	if v.Date != "" {
+		date := html.EscapeString(v.Date)
+		if _, err := time.Parse("2006-01-02", v.Date); err == nil {
+			title += ` <time datetime="` + date + `">` + date + "</time>"
+		} else {
+			title += ` <span class="date">` + date + "</span>"
+		}
+	}
+	if v.Yanked {
+		title += ` <strong class="yanked">YANKED</strong>`
+	}
+	return title
+}
+
+var htmlHeadingRegexp = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*$`)
+
+// renderHTMLProse renders markdown prose as headings and paragraphs, one
+// for each block of lines.
+func renderHTMLProse(markdown, indent string) string {
+	blocks := []string{}
+	for _, block := range strings.Split(strings.TrimSpace(markdown), "\n\n") {
+		block = strings.TrimSpace(block)
+		if block == "" {
+			continue
+		}
+		var b strings.Builder
+		b.WriteString(indent)
+		if matches := htmlHeadingRegexp.FindStringSubmatch(block); matches != nil && !strings.Contains(block, "\n") {
+			tag := "h" + strconv.Itoa(len(matches[1]))
+			b.WriteString("<" + tag + ">")
+			renderInlineMarkdown(&b, matches[2])
+			b.WriteString("</" + tag + ">")
+		} else {
+			b.WriteString("<p>")
+			renderInlineMarkdown(&b, block)
+			b.WriteString("</p>")
+		}
+		blocks = append(blocks, b.String())
+	}
+	return strings.Join(blocks, "\n")
+}
+
+// markdownPunctuation holds the characters which can be escaped with a
+// backslash in markdown.
+const markdownPunctuation = "\\`*_{}[]()#+-.!<>"
+
+// renderInlineMarkdown writes the text as HTML, rendering code spans,
+// links, and strong and regular emphasis. Everything else is escaped.
+func renderInlineMarkdown(b *strings.Builder, text string) {
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '\\' && i+1 < len(text) && strings.IndexByte(markdownPunctuation, text[i+1]) >= 0:
+			b.WriteString(html.EscapeString(text[i+1 : i+2]))
+			i += 2
+			continue
+		case c == '`':
+			n := len(text[i:]) - len(strings.TrimLeft(text[i:], "`"))
+			fence := text[i : i+n]
+			if end := strings.Index(text[i+n:], fence); end >= 0 {
+				code := text[i+n : i+n+end]
+				if len(code) > 1 && code[0] == ' ' && code[len(code)-1] == ' ' {
+					code = code[1 : len(code)-1]
+				}
+				b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+				i += n + end + n
+				continue
+			}
+			b.WriteString(fence)
+			i += n
+			continue
+		case c == '[':
+			if label, url, end, ok := parseInlineLink(text, i); ok && isSafeURL(url) {
+				b.WriteString(`<a href="` + html.EscapeString(url) + `">`)
+				renderInlineMarkdown(b, label)
+				b.WriteString("</a>")
+				i = end
+				continue
+			}
+		case c == '*' || c == '_':
+			if tag, inner, end, ok := parseEmphasis(text, i); ok {
+				b.WriteString("<" + tag + ">")
+				renderInlineMarkdown(b, inner)
+				b.WriteString("</" + tag + ">")
+				i = end
+				continue
+			}
+		}
+		b.WriteString(html.EscapeString(text[i : i+1]))
+		i++
+	}
+}
+
+// parseInlineLink parses the link "[label](url)" starting at the given
+// position, returning the position after it.
+func parseInlineLink(text string, start int) (label, url string, end int, ok bool) {
+	depth := 0
+	for i := start; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth > 0 {
+				continue
+			}
+			if i+1 >= len(text) || text[i+1] != '(' {
+				return "", "", 0, false
+			}
+			closing := strings.IndexByte(text[i+2:], ')')
+			if closing < 0 {
+				return "", "", 0, false
+			}
+			url = strings.TrimSpace(text[i+2 : i+2+closing])
+			if url == "" || strings.ContainsAny(url, " \t\n") {
+				return "", "", 0, false
+			}
+			return text[start+1 : i], url, i + 2 + closing + 1, true
+		}
+	}
+	return "", "", 0, false
+}
+
+// parseEmphasis parses the emphasis starting at the given position, e.g.
+// "*word*" or "__words__", returning the tag to render it with and the
+// position after it.
+func parseEmphasis(text string, start int) (tag, inner string, end int, ok bool) {
+	delim := text[start : start+1]
+	tag = "em"
+	if strings.HasPrefix(text[start:], delim+delim) {
+		delim += delim
+		tag = "strong"
+	}
+	isWord := func(i int) bool {
+		if i < 0 || i >= len(text) {
+			return false
+		}
+		c := text[i]
+		return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+	}
+
+	open := start + len(delim)
+	if open >= len(text) || text[open] == ' ' || delim[0] == '_' && isWord(start-1) {
+		return "", "", 0, false
+	}
+	for i := open + 1; i+len(delim) <= len(text); i++ {
+		if text[i:i+len(delim)] != delim || text[i-1] == ' ' {
+			continue
+		}
+		after := i + len(delim)
+		if len(delim) == 1 && after < len(text) && text[after] == delim[0] {
+			// Part of a strong emphasis.
+			i++
+			continue
+		}
+		if delim[0] == '_' && isWord(after) {
+			continue
+		}
+		return tag, text[open:i], after, true
+	}
+	return "", "", 0, false
+}
+
+// isSafeURL checks that the URL is relative, or uses a scheme which can
+// be linked to safely.
+func isSafeURL(url string) bool {
+	colon := strings.IndexByte(url, ':')
+	if colon < 0 || strings.IndexAny(url[:colon], "/?#") >= 0 {
+		return true
+	}
+	switch strings.ToLower(url[:colon]) {
+	case "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+// HTMLPage is what the template of a full HTML page is executed with.
+type HTMLPage struct {
+	// Title is the first heading of the changelog's preamble, or
+	// "Changelog".
+	Title string
+	// Body is the changelog rendered as HTML.
+	Body template.HTML
+	// Changelog is the changelog being rendered.
+	Changelog *Changelog
+}
+
+// DefaultHTMLPageTemplate is the template RenderPage uses if it's passed
+// none.
+var DefaultHTMLPageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+</head>
+<body>
+{{.Body}}</body>
+</html>
+`))
+
+// RenderPage writes the changelog as a full HTML page by executing the
+// template with an HTMLPage. If page is nil, DefaultHTMLPageTemplate is
+// used.
+func (r *HTMLRenderer) RenderPage(w io.Writer, c *Changelog, page *template.Template) error {
+	if page == nil {
+		page = DefaultHTMLPageTemplate
+	}
+	return page.Execute(w, &HTMLPage{
+		Title:     htmlTitle(c),
+		Body:      template.HTML(r.Render(c)),
+		Changelog: c,
+	})
+}
+
+// htmlTitle returns the first heading of the changelog's preamble.
+func htmlTitle(c *Changelog) string {
+	for _, line := range strings.Split(c.Preamble, "\n") {
+		if matches := htmlHeadingRegexp.FindStringSubmatch(strings.TrimSpace(line)); matches != nil {
+			return matches[2]
+		}
+	}
+	return "Changelog"
+}
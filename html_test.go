@@ -0,0 +1,117 @@
+package changelog
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderInlineMarkdown(t *testing.T) {
+	for _, tc := range []struct{ markdown, html string }{
+		{"Fix the tokenizer", "Fix the tokenizer"},
+		{"Escape <script> & \"quotes\"", "Escape &lt;script&gt; &amp; &#34;quotes&#34;"},
+		{"Add `site.data` & `<br>`", "Add <code>site.data</code> &amp; <code>&lt;br&gt;</code>"},
+		{"Use `` a`b ``", "Use <code>a`b</code>"},
+		{"Unclosed `code", "Unclosed `code"},
+		{"See [the docs](https://jekyllrb.com/docs/)", `See <a href="https://jekyllrb.com/docs/">the docs</a>`},
+		{"See [the *new* docs](/docs/)", `See <a href="/docs/">the <em>new</em> docs</a>`},
+		{"Don't [click](javascript:alert(1))", "Don&#39;t [click](javascript:alert(1))"},
+		{"[Not a link] (https://example.com)", "[Not a link] (https://example.com)"},
+		{"Make it *fast* and **safe**", "Make it <em>fast</em> and <strong>safe</strong>"},
+		{"Make it _fast_ and __safe__", "Make it <em>fast</em> and <strong>safe</strong>"},
+		{"Rename snake_case_names", "Rename snake_case_names"},
+		{"2 * 3 * 4", "2 * 3 * 4"},
+		{`Escape \*stars\*`, "Escape *stars*"},
+	} {
+		var b strings.Builder
+		renderInlineMarkdown(&b, tc.markdown)
+		assert.Equal(t, tc.html, b.String(), tc.markdown)
+	}
+}
+
+func TestHTMLRenderer_RenderVersion(t *testing.T) {
+	resolver, err := NewResolver("https://github.com/parkr/changelog")
+	assert.NoError(t, err)
+	version := NewVersion("1.0.0")
+	version.Date = "2015-02-20"
+	version.URL = "https://github.com/parkr/changelog/releases/tag/v1.0.0"
+	version.Description = "The first *stable* release."
+	version.History = []*ChangeLine{{Summary: "Add `HTML`", Reference: "#12, @parkr"}}
+	version.Subsections = []*Subsection{{
+		Name:    "Bug Fixes & Co",
+		History: []*ChangeLine{{Summary: "Fix <b>", Reference: "https://example.com/a?b&c"}},
+	}}
+
+	actual := (&HTMLRenderer{Resolver: resolver, IDPrefix: "changelog-"}).RenderVersion(version)
+
+	assert.Equal(t, `<section id="changelog-v1.0.0" class="version">
+  <h2><a href="https://github.com/parkr/changelog/releases/tag/v1.0.0">1.0.0</a> <time datetime="2015-02-20">2015-02-20</time></h2>
+  <p>The first <em>stable</em> release.</p>
+  <ul>
+    <li>Add <code>HTML</code> (<a href="https://github.com/parkr/changelog/issues/12">#12</a>, <a href="https://github.com/parkr">@parkr</a>)</li>
+  </ul>
+  <section class="subsection">
+    <h3>Bug Fixes &amp; Co</h3>
+    <ul>
+      <li>Fix &lt;b&gt; (<a href="https://example.com/a?b&amp;c">https://example.com/a?b&amp;c</a>)</li>
+    </ul>
+  </section>
+</section>`, actual)
+}
+
+func TestHTMLRenderer_VersionHeadings(t *testing.T) {
+	renderer := &HTMLRenderer{}
+	for _, tc := range []struct {
+		version, date string
+		yanked        bool
+		heading       string
+	}{
+		{"HEAD", "", false, `<section id="unreleased" class="version">
+  <h2>Unreleased</h2>`},
+		{"[Unreleased]", "", false, `<section id="unreleased" class="version">
+  <h2>Unreleased</h2>`},
+		{"[0.0.5]", "2014-08-09", true, `<section id="v0.0.5" class="version">
+  <h2>0.0.5 <time datetime="2014-08-09">2014-08-09</time> <strong class="yanked">YANKED</strong></h2>`},
+		{"Old Versions", "long ago", false, `<section id="old-versions" class="version">
+  <h2>Old Versions <span class="date">long ago</span></h2>`},
+	} {
+		version := NewVersion(tc.version)
+		version.Date = tc.date
+		version.Yanked = tc.yanked
+		assert.True(t, strings.HasPrefix(renderer.RenderVersion(version), tc.heading), tc.version)
+	}
+
+	assert.Equal(t, "<section class=\"version\">\n  <ul>\n    <li>A change</li>\n  </ul>\n</section>",
+		renderer.RenderVersion(&Version{History: []*ChangeLine{{Summary: "A change"}}, sortOrder: -1}))
+}
+
+func TestChangelogHTML(t *testing.T) {
+	history := parseKeepAChangelog(t)
+
+	actual := history.HTML()
+
+	assert.True(t, strings.HasPrefix(actual, "<h1>Changelog</h1>\n<p>All notable changes"), actual)
+	assert.Contains(t, actual, `<p>The format is based on <a href="https://keepachangelog.com/en/1.1.0/">Keep a Changelog</a>,`)
+	assert.Contains(t, actual, `<h2><a href="https://github.com/olivierlacan/keep-a-changelog/compare/v1.1.0...v1.1.1">1.1.1</a> <time datetime="2023-03-05">2023-03-05</time></h2>`)
+	assert.Contains(t, actual, "<li>Arabic translation (#444).</li>")
+	assert.True(t, strings.HasSuffix(actual, "</section>\n"))
+}
+
+func TestHTMLRenderer_RenderPage(t *testing.T) {
+	history := parseKeepAChangelog(t)
+	renderer := &HTMLRenderer{}
+
+	var page bytes.Buffer
+	assert.NoError(t, renderer.RenderPage(&page, history, nil))
+	assert.True(t, strings.HasPrefix(page.String(), "<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n<title>Changelog</title>"))
+	assert.Contains(t, page.String(), "<body>\n"+renderer.Render(history)+"</body>\n</html>\n")
+
+	custom := template.Must(template.New("page").Parse("<title>{{.Title}} of {{len .Changelog.Versions}} versions</title>\n{{.Body}}"))
+	page.Reset()
+	history.Preamble = "# <Keep> a changelog"
+	assert.NoError(t, renderer.RenderPage(&page, history, custom))
+	assert.True(t, strings.HasPrefix(page.String(), "<title>&lt;Keep&gt; a changelog of 5 versions</title>\n<h1>&lt;Keep&gt; a changelog</h1>"), page.String())
+}
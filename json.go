@@ -0,0 +1,322 @@
+package changelog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// The JSON encoding of a changelog is described by changelog.schema.json.
+// It is meant to be stable: fields are only ever added to it.
+//
+// A changelog is encoded as
+//
+//	{
+//	  "dialect": "jekyll",
+//	  "style": {"bullet": "*", "indent": 2, "headerDepth": 2, "dateSeparator": " / "},
+//	  "preamble": "",
+//	  "versions": [...],
+//	  "epilogue": ""
+//	}
+//
+// and each version as
+//
+//	{
+//	  "kind": "release",
+//	  "version": "1.0.0",
+//	  "date": "2015-02-20",
+//	  "history": [{"summary": "Fix the tokenizer", "reference": "#123", "references": [...]}],
+//	  "subsections": [{"name": "Bug Fixes", "history": [...]}]
+//	}
+//
+// The kind of a version is "unversioned" for the changes above the first
+// version header, "unreleased" for the unreleased changes and "release"
+// for everything else. Versions are listed in the order they are written,
+// which is the order of their kinds, then of the releases in the array.
+
+const (
+	// VersionKindUnversioned is the kind of the changes which come before
+	// the first version header.
+	VersionKindUnversioned = "unversioned"
+	// VersionKindUnreleased is the kind of the changes which haven't been
+	// released yet, "HEAD" or "[Unreleased]".
+	VersionKindUnreleased = "unreleased"
+	// VersionKindRelease is the kind of every released version.
+	VersionKindRelease = "release"
+)
+
+// Kind returns the kind of the version: VersionKindUnversioned,
+// VersionKindUnreleased or VersionKindRelease.
+func (v *Version) Kind() string {
+	switch {
+	case v.sortOrder < 0:
+		return VersionKindUnversioned
+	case v.sortOrder == 0:
+		return VersionKindUnreleased
+	}
+	return VersionKindRelease
+}
+
+type changelogJSON struct {
+	Dialect     string            `json:"dialect"`
+	Style       *styleJSON        `json:"style,omitempty"`
+	Preamble    string            `json:"preamble,omitempty"`
+	Versions    []*Version        `json:"versions"`
+	Epilogue    string            `json:"epilogue,omitempty"`
+	Diagnostics []*diagnosticJSON `json:"diagnostics,omitempty"`
+}
+
+type styleJSON struct {
+	Bullet        string `json:"bullet,omitempty"`
+	Indent        *int   `json:"indent,omitempty"`
+	IndentWith    string `json:"indentWith,omitempty"`
+	HeaderDepth   int    `json:"headerDepth,omitempty"`
+	DateSeparator string `json:"dateSeparator,omitempty"`
+	LineEnding    string `json:"lineEnding,omitempty"`
+}
+
+type diagnosticJSON struct {
+	Line   int    `json:"line"`
+	Column int    `json:"column,omitempty"`
+	Text   string `json:"text,omitempty"`
+	Reason string `json:"reason"`
+}
+
+type versionJSON struct {
+	Kind        string        `json:"kind"`
+	Version     string        `json:"version,omitempty"`
+	Date        string        `json:"date,omitempty"`
+	Yanked      bool          `json:"yanked,omitempty"`
+	URL         string        `json:"url,omitempty"`
+	URLLabel    string        `json:"urlLabel,omitempty"`
+	Description string        `json:"description,omitempty"`
+	History     []*ChangeLine `json:"history,omitempty"`
+	Subsections []*Subsection `json:"subsections,omitempty"`
+}
+
+type subsectionJSON struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	History     []*ChangeLine `json:"history,omitempty"`
+}
+
+type changeLineJSON struct {
+	Summary    string           `json:"summary"`
+	Reference  string           `json:"reference,omitempty"`
+	References []*referenceJSON `json:"references,omitempty"`
+}
+
+type referenceJSON struct {
+	Kind string `json:"kind"`
+	Text string `json:"text"`
+	ID   string `json:"id"`
+	Repo string `json:"repo,omitempty"`
+}
+
+// MarshalJSON encodes the changelog as described by changelog.schema.json.
+// The Dialect is encoded by name, and the Style as it is used to write the
+// changelog.
+func (c *Changelog) MarshalJSON() ([]byte, error) {
+	c.sortVersions()
+	style := c.style()
+	out := changelogJSON{
+		Dialect: c.dialect().Name(),
+		Style: &styleJSON{
+			Bullet:        style.Bullet,
+			Indent:        &style.Indent,
+			IndentWith:    style.IndentWith,
+			HeaderDepth:   style.HeaderDepth,
+			DateSeparator: style.DateSeparator,
+			LineEnding:    style.LineEnding,
+		},
+		Preamble: c.Preamble,
+		Versions: c.Versions,
+		Epilogue: c.Epilogue,
+	}
+	if out.Versions == nil {
+		out.Versions = []*Version{}
+	}
+	for _, diagnostic := range c.Diagnostics {
+		out.Diagnostics = append(out.Diagnostics, &diagnosticJSON{
+			Line:   diagnostic.Line,
+			Column: diagnostic.Column,
+			Text:   diagnostic.Text,
+			Reason: diagnostic.Reason,
+		})
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes a changelog encoded by MarshalJSON. An unknown
+// dialect is an error; a missing one means Jekyll. The versions keep their
+// order within each kind.
+func (c *Changelog) UnmarshalJSON(data []byte) error {
+	var in changelogJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	dialect := Jekyll
+	if in.Dialect != "" {
+		var err error
+		if dialect, err = DialectByName(in.Dialect); err != nil {
+			return err
+		}
+	}
+
+	*c = Changelog{
+		Preamble: in.Preamble,
+		Versions: in.Versions,
+		Epilogue: in.Epilogue,
+		Dialect:  dialect,
+	}
+	if c.Versions == nil {
+		c.Versions = []*Version{}
+	}
+	if in.Style != nil {
+		c.Style = &Style{
+			Bullet:        in.Style.Bullet,
+			Indent:        dialect.DefaultStyle().Indent,
+			IndentWith:    in.Style.IndentWith,
+			HeaderDepth:   in.Style.HeaderDepth,
+			DateSeparator: in.Style.DateSeparator,
+			LineEnding:    in.Style.LineEnding,
+		}
+		// An indent of 0 is meaningful, so only a missing one is taken
+		// from the dialect's default style.
+		if in.Style.Indent != nil {
+			c.Style.Indent = *in.Style.Indent
+		}
+	}
+	for _, diagnostic := range in.Diagnostics {
+		c.Diagnostics = append(c.Diagnostics, &ParseError{
+			Line:   diagnostic.Line,
+			Column: diagnostic.Column,
+			Text:   diagnostic.Text,
+			Reason: diagnostic.Reason,
+		})
+	}
+
+	sortOrder := 1
+	for _, v := range c.Versions {
+		if v == nil {
+			return fmt.Errorf("version can't be null")
+		}
+		if v.sortOrder > 0 {
+			v.sortOrder = sortOrder
+			sortOrder++
+		}
+	}
+	c.sortVersions()
+	return nil
+}
+
+// MarshalJSON encodes the version along with its kind.
+func (v *Version) MarshalJSON() ([]byte, error) {
+	return json.Marshal(versionJSON{
+		Kind:        v.Kind(),
+		Version:     v.Version,
+		Date:        v.Date,
+		Yanked:      v.Yanked,
+		URL:         v.URL,
+		URLLabel:    v.urlLabel,
+		Description: v.Description,
+		History:     v.History,
+		Subsections: v.Subsections,
+	})
+}
+
+// UnmarshalJSON decodes a version encoded by MarshalJSON. If the kind is
+// missing, it is worked out from the version number as NewVersion does.
+func (v *Version) UnmarshalJSON(data []byte) error {
+	var in versionJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*v = Version{
+		Version:     in.Version,
+		Date:        in.Date,
+		Yanked:      in.Yanked,
+		URL:         in.URL,
+		Description: in.Description,
+		History:     in.History,
+		Subsections: in.Subsections,
+		urlLabel:    in.URLLabel,
+	}
+	switch in.Kind {
+	case VersionKindUnversioned:
+		v.sortOrder = -1
+	case VersionKindUnreleased:
+		v.sortOrder = 0
+	case VersionKindRelease:
+		v.sortOrder = 1
+	case "":
+		v.sortOrder = NewVersion(in.Version).sortOrder
+	default:
+		return fmt.Errorf("unknown version kind %q", in.Kind)
+	}
+	if v.History == nil {
+		v.History = []*ChangeLine{}
+	}
+	if v.Subsections == nil {
+		v.Subsections = []*Subsection{}
+	}
+	return nil
+}
+
+// MarshalJSON encodes the subsection.
+func (s *Subsection) MarshalJSON() ([]byte, error) {
+	return json.Marshal(subsectionJSON{
+		Name:        s.Name,
+		Description: s.Description,
+		History:     s.History,
+	})
+}
+
+// UnmarshalJSON decodes a subsection encoded by MarshalJSON.
+func (s *Subsection) UnmarshalJSON(data []byte) error {
+	var in subsectionJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*s = Subsection{Name: in.Name, Description: in.Description, History: in.History}
+	if s.History == nil {
+		s.History = []*ChangeLine{}
+	}
+	return nil
+}
+
+// MarshalJSON encodes the change. Along with the Reference as written, the
+// individual references are listed with their kind, as returned by
+// References. They are only informational: decoding ignores them.
+func (l *ChangeLine) MarshalJSON() ([]byte, error) {
+	out := changeLineJSON{Summary: l.Summary, Reference: l.Reference}
+	for _, ref := range l.References() {
+		out.References = append(out.References, &referenceJSON{
+			Kind: ref.Kind.String(),
+			Text: ref.Text,
+			ID:   ref.ID,
+			Repo: ref.Repo,
+		})
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes a change encoded by MarshalJSON.
+func (l *ChangeLine) UnmarshalJSON(data []byte) error {
+	var in changeLineJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*l = ChangeLine{Summary: in.Summary, Reference: in.Reference}
+	return nil
+}
+
+// NewChangelogFromJSON builds a changelog from its JSON encoding, read in
+// through the reader it's passed.
+func NewChangelogFromJSON(reader io.Reader) (*Changelog, error) {
+	history := &Changelog{}
+	if err := json.NewDecoder(reader).Decode(history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
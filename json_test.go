@@ -0,0 +1,177 @@
+package changelog
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangelogJSON_RoundTrip(t *testing.T) {
+	for _, filename := range []string{
+		"testdata/History.markdown",
+		"testdata/keep-a-changelog.md",
+		"testdata/changelog-prose.md",
+		"History.markdown",
+	} {
+		source, err := ioutil.ReadFile(filename)
+		assert.NoError(t, err)
+		history, err := NewChangelogFromFile(filename)
+		assert.NoError(t, err)
+
+		data, err := json.Marshal(history)
+		assert.NoError(t, err, filename)
+		decoded, err := NewChangelogFromJSON(strings.NewReader(string(data)))
+		assert.NoError(t, err, filename)
+
+		assert.Equal(t, string(source), decoded.String(), filename)
+	}
+}
+
+func TestChangelogJSON_Encoding(t *testing.T) {
+	history := NewChangelog()
+	history.Preamble = "# Changelog"
+	head := history.GetVersionOrCreate("HEAD")
+	head.History = []*ChangeLine{{Summary: "Add JSON", Reference: "#12, @parkr"}}
+	release := history.GetVersionOrCreate("1.0.0")
+	release.Date = "2015-02-20"
+	release.Subsections = []*Subsection{{Name: "Bug Fixes", History: []*ChangeLine{{Summary: "Fix the tokenizer"}}}}
+
+	data, err := json.Marshal(history)
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"dialect": "jekyll",
+		"style": {"bullet": "*", "indent": 2, "headerDepth": 2, "dateSeparator": " / "},
+		"preamble": "# Changelog",
+		"versions": [
+			{
+				"kind": "unreleased",
+				"version": "HEAD",
+				"history": [{
+					"summary": "Add JSON",
+					"reference": "#12, @parkr",
+					"references": [
+						{"kind": "issue", "text": "#12", "id": "12"},
+						{"kind": "user", "text": "@parkr", "id": "parkr"}
+					]
+				}]
+			},
+			{
+				"kind": "release",
+				"version": "1.0.0",
+				"date": "2015-02-20",
+				"subsections": [{"name": "Bug Fixes", "history": [{"summary": "Fix the tokenizer"}]}]
+			}
+		]
+	}`, string(data))
+}
+
+func TestChangelogJSON_Decoding(t *testing.T) {
+	history, err := NewChangelogFromJSON(strings.NewReader(`{
+		"dialect": "keep-a-changelog",
+		"versions": [
+			{"version": "1.0.0", "history": [{"summary": "Initial release"}]},
+			{"version": "1.1.0", "history": [{"summary": "Add JSON", "reference": "#12"}]},
+			{"kind": "unreleased", "version": "[Unreleased]"}
+		]
+	}`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, KeepAChangelog, history.Dialect)
+	assert.Nil(t, history.Style)
+	assert.Equal(t, VersionKindUnreleased, history.Versions[0].Kind())
+	assert.Equal(t, "1.0.0", history.Versions[1].Version)
+	assert.Equal(t, VersionKindRelease, history.Versions[1].Kind())
+	assert.Equal(t, "1.1.0", history.Versions[2].Version)
+	assert.Equal(t, "## [Unreleased]\n\n## [1.0.0]\n\n- Initial release\n\n## [1.1.0]\n\n- Add JSON (#12)\n", history.String())
+}
+
+func TestChangelogJSON_DecodingPartialStyle(t *testing.T) {
+	history, err := NewChangelogFromJSON(strings.NewReader(`{
+		"dialect": "jekyll",
+		"style": {"bullet": "-"},
+		"versions": [{"kind": "unreleased", "version": "HEAD", "history": [{"summary": "x"}]}]
+	}`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, &Style{Bullet: "-", Indent: 2}, history.Style)
+	assert.Equal(t, "## HEAD\n\n  - x\n", history.String())
+
+	history, err = NewChangelogFromJSON(strings.NewReader(`{
+		"style": {"indent": 0},
+		"versions": [{"kind": "unreleased", "version": "HEAD", "history": [{"summary": "x"}]}]
+	}`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "## HEAD\n\n* x\n", history.String())
+
+	history, err = NewChangelogFromJSON(strings.NewReader(`{
+		"style": {"indent": 1, "indentWith": "\t"},
+		"versions": [{"kind": "unreleased", "version": "HEAD", "history": [{"summary": "x"}]}]
+	}`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "## HEAD\n\n\t* x\n", history.String())
+}
+
+func TestChangelogJSON_DecodingErrors(t *testing.T) {
+	for _, input := range []string{
+		`{"dialect": "rst", "versions": []}`,
+		`{"versions": [{"kind": "draft", "version": "1.0.0"}]}`,
+		`{"versions": [null]}`,
+		`{"versions": {}}`,
+	} {
+		_, err := NewChangelogFromJSON(strings.NewReader(input))
+		assert.Error(t, err, input)
+	}
+}
+
+func TestVersionKind(t *testing.T) {
+	assert.Equal(t, VersionKindUnversioned, NewVersion("").Kind())
+	assert.Equal(t, VersionKindUnreleased, NewVersion("HEAD").Kind())
+	assert.Equal(t, VersionKindUnreleased, NewVersion("[Unreleased]").Kind())
+	assert.Equal(t, VersionKindRelease, NewVersion("1.0.0").Kind())
+}
+
+// TestChangelogJSON_Schema checks that every field which is encoded is
+// described by changelog.schema.json.
+func TestChangelogJSON_Schema(t *testing.T) {
+	data, err := ioutil.ReadFile("changelog.schema.json")
+	assert.NoError(t, err)
+	var schema map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &schema))
+
+	history, err := NewChangelogFromFile("testdata/lint.md")
+	assert.NoError(t, err)
+	history.GetVersionOrCreate("HEAD").History = []*ChangeLine{{Summary: "Cross-repo", Reference: "parkr/changelog#1"}}
+	data, err = json.Marshal(history)
+	assert.NoError(t, err)
+	var encoded interface{}
+	assert.NoError(t, json.Unmarshal(data, &encoded))
+
+	checkSchemaProperties(t, schema, schema, encoded, "$")
+}
+
+func checkSchemaProperties(t *testing.T, root, schema map[string]interface{}, value interface{}, path string) {
+	if ref, ok := schema["$ref"].(string); ok {
+		schema = root["$defs"].(map[string]interface{})[strings.TrimPrefix(ref, "#/$defs/")].(map[string]interface{})
+	}
+	switch value := value.(type) {
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		for key, field := range value {
+			property, ok := properties[key].(map[string]interface{})
+			if assert.True(t, ok, "%s.%s isn't in the schema", path, key) {
+				checkSchemaProperties(t, root, property, field, path+"."+key)
+			}
+		}
+	case []interface{}:
+		items, _ := schema["items"].(map[string]interface{})
+		for _, item := range value {
+			checkSchemaProperties(t, root, items, item, path+"[]")
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package changelog
+
+import (
+	"regexp"
+	"strings"
+)
+
+// The subsections defined by Keep a Changelog (https://keepachangelog.com).
+const (
+	Added      = "Added"
+	Changed    = "Changed"
+	Deprecated = "Deprecated"
+	Removed    = "Removed"
+	Fixed      = "Fixed"
+	Security   = "Security"
+)
+
+// KeepAChangelogSubsections lists the subsections defined by Keep a
+// Changelog in the order it recommends.
+var KeepAChangelogSubsections = []string{Added, Changed, Deprecated, Removed, Fixed, Security}
+
+var (
+	yankedRegexp        = regexp.MustCompile(`(?i)\s+\[YANKED\]\s*\z`)
+	linkDefRegexp       = regexp.MustCompile(`^\[([^\]]+)\]:\s*(\S+)\s*\z`)
+	compareURLRegexp    = regexp.MustCompile(`^(.+)/compare/(v?)(.+?)\.\.\.(v?)(.+)\z`)
+	releaseTagURLRegexp = regexp.MustCompile(`^(.+)/releases/tag/(v?)(.+)\z`)
+)
+
+// isKeepAChangelogSubsection checks whether the name is one of the
+// subsections defined by Keep a Changelog, and returns its position in
+// KeepAChangelogSubsections.
+func isKeepAChangelogSubsection(name string) (int, bool) {
+	for i, standard := range KeepAChangelogSubsections {
+		if strings.EqualFold(name, standard) {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// linkLabel returns the label used for the version in its link reference
+// definition, e.g. "1.0.0" for "[1.0.0]: https://...".
+func (v *Version) linkLabel(d Dialect) string {
+	label := v.Version
+	if isUnreleased(label) {
+		label = d.Unreleased()
+	}
+	label = strings.TrimSuffix(strings.TrimPrefix(label, "["), "]")
+	// Keep the label as it was written, unless it was written for another
+	// dialect's name of the unreleased version.
+	if v.urlLabel != "" && strings.EqualFold(v.urlLabel, label) {
+		return v.urlLabel
+	}
+	return label
+}
+
+// versionForLinkLabel fetches the Version a link reference definition
+// label refers to. Labels are case-insensitive, as in markdown.
+func (c *Changelog) versionForLinkLabel(label string) *Version {
+	for _, v := range c.Versions {
+		if v.Version != "" && strings.EqualFold(strings.Trim(v.Version, "[]"), label) {
+			return v
+		}
+	}
+	return nil
+}
+
+// parseLinks moves the link reference definitions for versions from the
+// Epilogue onto the URL of each Version.
+func (c *Changelog) parseLinks() {
+	if c.Epilogue == "" {
+		return
+	}
+	remaining := []string{}
+	for _, line := range strings.Split(c.Epilogue, "\n") {
+		if matches := linkDefRegexp.FindStringSubmatch(line); matches != nil {
+			if v := c.versionForLinkLabel(matches[1]); v != nil && v.URL == "" {
+				v.URL = matches[2]
+				v.urlLabel = matches[1]
+				continue
+			}
+		}
+		remaining = append(remaining, line)
+	}
+	c.Epilogue = strings.Join(trimBlankLines(remaining), "\n")
+}
+
+// linkTemplate describes how the links to each version are built, e.g.
+// "https://github.com/parkr/changelog" with tags named "v1.0.0".
+type linkTemplate struct {
+	base      string
+	tagPrefix string
+}
+
+// compareURL links to the changes between two tags, or between a tag and
+// HEAD.
+func (t *linkTemplate) compareURL(from, to string) string {
+	if to != "HEAD" {
+		to = t.tagPrefix + to
+	}
+	return t.base + "/compare/" + t.tagPrefix + from + "..." + to
+}
+
+// releaseURL links to a single tag.
+func (t *linkTemplate) releaseURL(version string) string {
+	return t.base + "/releases/tag/" + t.tagPrefix + version
+}
+
+// detectLinkTemplate looks for a compare or release URL among the
+// versions' links to learn how new links should be built.
+func (c *Changelog) detectLinkTemplate() *linkTemplate {
+	for _, v := range c.Versions {
+		if matches := compareURLRegexp.FindStringSubmatch(v.URL); matches != nil {
+			return &linkTemplate{base: matches[1], tagPrefix: matches[2]}
+		}
+	}
+	for _, v := range c.Versions {
+		if matches := releaseTagURLRegexp.FindStringSubmatch(v.URL); matches != nil {
+			return &linkTemplate{base: matches[1], tagPrefix: matches[2]}
+		}
+	}
+	return nil
+}
+
+// updateLinks fixes compare URLs whose starting point is no longer the
+// previous version, e.g. the Unreleased link after a version has been
+// added, and compare URLs to HEAD for versions which have since been
+// released. Versions which don't have a URL yet get one if the dialect
+// links every version. It does nothing if the changelog doesn't link its
+// versions.
+func (c *Changelog) updateLinks() {
+	template := c.detectLinkTemplate()
+	if template == nil {
+		return
+	}
+	for i, v := range c.Versions {
+		if v.sortOrder < 0 {
+			continue
+		}
+		var previous *Version
+		for _, candidate := range c.Versions[i+1:] {
+			if candidate.sortOrder > 0 {
+				previous = candidate
+				break
+			}
+		}
+
+		to := strings.TrimPrefix(v.Version, template.tagPrefix)
+		if v.sortOrder == 0 {
+			to = "HEAD"
+		}
+		var expected string
+		switch {
+		case previous != nil:
+			expected = template.compareURL(strings.TrimPrefix(previous.Version, template.tagPrefix), to)
+		case v.sortOrder > 0:
+			expected = template.releaseURL(to)
+		default:
+			// Nothing has been released yet, so there's nothing to compare to.
+			continue
+		}
+
+		if v.URL == "" {
+			if c.dialect().LinksVersions() {
+				v.URL = expected
+			}
+			continue
+		}
+		current := compareURLRegexp.FindStringSubmatch(v.URL)
+		isStale := current != nil && current[1] == template.base && (current[5] == to || current[5] == "HEAD")
+		if isStale && v.URL != expected {
+			v.URL = expected
+		}
+	}
+}
+
+// links returns the link reference definitions for each version which
+// has a URL.
+func (c *Changelog) links() string {
+	links := []string{}
+	for _, v := range c.Versions {
+		if v.URL != "" {
+			links = append(links, "["+v.linkLabel(c.dialect())+"]: "+v.URL)
+		}
+	}
+	return strings.Join(links, "\n")
+}
@@ -0,0 +1,102 @@
+package changelog
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func parseKeepAChangelog(t *testing.T) *Changelog {
+	history, err := NewChangelogFromFile("testdata/keep-a-changelog.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return history
+}
+
+// lastLines returns the last n lines of the markdown.
+func lastLines(markdown string, n int) string {
+	lines := strings.Split(strings.TrimSuffix(markdown, "\n"), "\n")
+	return strings.Join(lines[len(lines)-n:], "\n")
+}
+
+func TestParseKeepAChangelog(t *testing.T) {
+	history := parseKeepAChangelog(t)
+
+	assert.Equal(t, KeepAChangelog, history.Dialect)
+	assert.Len(t, history.Versions, 5)
+	assert.Equal(t, "[Unreleased]", history.Versions[0].Version)
+	assert.Equal(t, "https://github.com/olivierlacan/keep-a-changelog/compare/v1.1.1...HEAD", history.Versions[0].URL)
+	assert.Equal(t, "1.1.1", history.Versions[1].Version)
+	assert.Equal(t, "2023-03-05", history.Versions[1].Date)
+	assert.False(t, history.Versions[1].Yanked)
+	assert.Equal(t, "https://github.com/olivierlacan/keep-a-changelog/compare/v1.1.0...v1.1.1", history.Versions[1].URL)
+	assert.Equal(t, []string{Added, Fixed, Changed}, subsectionNames(history.Versions[1]))
+
+	yanked := history.GetVersion("0.0.5")
+	assert.True(t, yanked.Yanked)
+	assert.Equal(t, "2014-08-09", yanked.Date)
+
+	assert.Equal(t, "https://github.com/olivierlacan/keep-a-changelog/releases/tag/v0.0.1", history.GetVersion("0.0.1").URL)
+	assert.Empty(t, history.Epilogue)
+	assert.Empty(t, history.Diagnostics)
+}
+
+func TestKeepAChangelogString(t *testing.T) {
+	source, err := os.ReadFile("testdata/keep-a-changelog.md")
+	assert.NoError(t, err)
+	history := parseKeepAChangelog(t)
+
+	actual := history.String()
+
+	assert.Contains(t, actual, "\n## [Unreleased]\n")
+	assert.Contains(t, actual, "\n## [1.1.1] - 2023-03-05\n")
+	assert.Contains(t, actual, "\n## [0.0.5] - 2014-08-09 [YANKED]\n")
+	assert.Equal(t, lastLines(string(source), 5), lastLines(actual, 5))
+}
+
+func TestKeepAChangelogString_NewVersionLinks(t *testing.T) {
+	history := parseKeepAChangelog(t)
+	version := history.GetVersionOrCreate("1.2.0")
+
+	actual := history.String()
+
+	assert.Equal(t, "https://github.com/olivierlacan/keep-a-changelog/compare/v1.1.1...v1.2.0", version.URL)
+	assert.Equal(t, `[unreleased]: https://github.com/olivierlacan/keep-a-changelog/compare/v1.2.0...HEAD
+[1.2.0]: https://github.com/olivierlacan/keep-a-changelog/compare/v1.1.1...v1.2.0
+[1.1.1]: https://github.com/olivierlacan/keep-a-changelog/compare/v1.1.0...v1.1.1
+[1.1.0]: https://github.com/olivierlacan/keep-a-changelog/compare/v0.0.5...v1.1.0
+[0.0.5]: https://github.com/olivierlacan/keep-a-changelog/compare/v0.0.1...v0.0.5
+[0.0.1]: https://github.com/olivierlacan/keep-a-changelog/releases/tag/v0.0.1`, lastLines(actual, 6))
+}
+
+func TestVersionString_Yanked(t *testing.T) {
+	version := NewVersion("1.0.0")
+	version.Date = "2015-02-20"
+	version.Yanked = true
+	assert.Equal(t, "## 1.0.0 / 2015-02-20 [YANKED]", version.String())
+	assert.Equal(t, "## [1.0.0] - 2015-02-20 [YANKED]", version.Format(KeepAChangelog))
+}
+
+func TestGetSubsectionOrCreate_KeepAChangelogOrder(t *testing.T) {
+	history := parseKeepAChangelog(t)
+
+	history.GetSubsectionOrCreate("[Unreleased]", Deprecated)
+	history.GetSubsectionOrCreate("[Unreleased]", "Internals")
+	history.GetSubsectionOrCreate("[Unreleased]", Security)
+
+	assert.Equal(t,
+		[]string{Added, Changed, Deprecated, Removed, "Internals", Security},
+		subsectionNames(history.GetVersion("[Unreleased]")),
+	)
+}
+
+func subsectionNames(v *Version) []string {
+	names := make([]string, len(v.Subsections))
+	for i, s := range v.Subsections {
+		names[i] = s.Name
+	}
+	return names
+}
@@ -0,0 +1,331 @@
+package changelog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Severity is how serious a LintFinding is.
+type Severity int
+
+const (
+	// SeverityOff disables a lint rule.
+	SeverityOff Severity = iota
+	// SeverityInfo is for suggestions.
+	SeverityInfo
+	// SeverityWarning is for problems which don't make the changelog
+	// wrong, but make it harder to read.
+	SeverityWarning
+	// SeverityError is for problems which make the changelog wrong.
+	SeverityError
+)
+
+// String returns the name of the severity, e.g. "warning".
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return "off"
+}
+
+// SeverityByName fetches the Severity with the given name, e.g. "warning".
+func SeverityByName(name string) (Severity, error) {
+	for s := SeverityOff; s <= SeverityError; s++ {
+		if strings.EqualFold(s.String(), name) {
+			return s, nil
+		}
+	}
+	return SeverityOff, fmt.Errorf("unknown severity %q", name)
+}
+
+// LintRule is a convention the linter checks changelogs against.
+type LintRule struct {
+	// ID identifies the rule in findings and configuration, e.g.
+	// "duplicate-version".
+	ID string
+	// Description explains what the rule checks.
+	Description string
+	// Severity is the severity of the rule's findings unless configured
+	// otherwise.
+	Severity Severity
+}
+
+// The IDs of the built-in lint rules.
+const (
+	RuleDuplicateVersion    = "duplicate-version"
+	RuleDuplicateSubsection = "duplicate-subsection"
+	RuleDateFormat          = "date-format"
+	RuleDateOrder           = "date-order"
+	RuleVersionOrder        = "version-order"
+	RuleEmptySubsection     = "empty-subsection"
+	RuleMissingReference    = "missing-reference"
+	RuleUnknownSubsection   = "unknown-subsection"
+	RuleUnparsedText        = "unparsed-text"
+)
+
+// LintRules lists the built-in lint rules.
+var LintRules = []*LintRule{
+	{RuleDuplicateVersion, "A version header appears more than once; the versions are merged.", SeverityError},
+	{RuleDuplicateSubsection, "A subsection header appears more than once in a version; the subsections are merged.", SeverityError},
+	{RuleDateFormat, "A release date isn't an ISO 8601 date, like 2006-01-02.", SeverityError},
+	{RuleDateOrder, "A release is dated after the release above it.", SeverityWarning},
+	{RuleVersionOrder, "A release has a higher semantic version than the release above it.", SeverityWarning},
+	{RuleEmptySubsection, "A subsection has no changes.", SeverityWarning},
+	{RuleMissingReference, "A change has no reference to an issue, pull request or user.", SeverityInfo},
+	{RuleUnknownSubsection, "A subsection isn't one of the known subsections for the dialect.", SeverityWarning},
+	{RuleUnparsedText, "The parser didn't recognize some text, like a header.", SeverityWarning},
+}
+
+// jekyllSubsections are the subsections used in Jekyll's History.markdown.
+var jekyllSubsections = []string{
+	"Major Enhancements",
+	"Minor Enhancements",
+	"Bug Fixes",
+	"Development Fixes",
+	"Site Enhancements",
+	"Documentation",
+}
+
+// LintConfig configures the linter. The zero value checks every rule at
+// its default severity.
+type LintConfig struct {
+	// Severities overrides the severity of rules by ID. SeverityOff
+	// disables a rule.
+	Severities map[string]Severity
+	// Subsections lists the known subsection names. If empty, the
+	// subsections of the changelog's Dialect are used: "Major
+	// Enhancements," "Minor Enhancements," "Bug Fixes" and so on for
+	// Jekyll, and KeepAChangelogSubsections for Keep a Changelog.
+	Subsections []string
+}
+
+// severity returns the configured severity of the rule.
+func (config *LintConfig) severity(rule *LintRule) Severity {
+	if config != nil {
+		if severity, ok := config.Severities[rule.ID]; ok {
+			return severity
+		}
+	}
+	return rule.Severity
+}
+
+// LintFinding is a problem the linter found in a changelog.
+type LintFinding struct {
+	// Rule is the ID of the rule which found the problem.
+	Rule     string
+	Severity Severity
+	// Line is the 1-based line number of the problem, or 0 if it isn't
+	// known, e.g. for a changelog which wasn't parsed.
+	Line    int
+	Message string
+}
+
+// String returns the position, severity, message and rule of the finding,
+// e.g. "line 3: error: duplicate version 1.0.0 (duplicate-version)".
+func (f *LintFinding) String() string {
+	str := fmt.Sprintf("%s: %s (%s)", f.Severity, f.Message, f.Rule)
+	if f.Line > 0 {
+		str = fmt.Sprintf("line %d: %s", f.Line, str)
+	}
+	return str
+}
+
+// Lint checks the changelog against the lint rules. The findings are
+// ordered by line. Since a Changelog doesn't remember where its parts came
+// from, the findings have no line numbers, and duplicate headers, which
+// were merged when parsing, can't be found. Use LintReader or LintFile to
+// lint a changelog's source instead.
+func (c *Changelog) Lint(config *LintConfig) []*LintFinding {
+	return newLinter(c, nil, config).lint()
+}
+
+// LintReader parses the changelog read in through the reader and checks it
+// against the lint rules. The findings are ordered by line.
+func LintReader(reader io.Reader, config *LintConfig) ([]*LintFinding, error) {
+	history := NewChangelog()
+	source := newSourceInfo()
+	if err := parseChangelogSource(reader, history, source); err != nil {
+		return nil, err
+	}
+	return newLinter(history, source, config).lint(), nil
+}
+
+// LintFile parses the changelog in the file at the provided filename and
+// checks it against the lint rules. The findings are ordered by line.
+func LintFile(filename string, config *LintConfig) ([]*LintFinding, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return LintReader(file, config)
+}
+
+// linter collects the findings for a changelog.
+type linter struct {
+	changelog *Changelog
+	source    *sourceInfo
+	config    *LintConfig
+	findings  []*LintFinding
+}
+
+func newLinter(c *Changelog, source *sourceInfo, config *LintConfig) *linter {
+	return &linter{changelog: c, source: source, config: config}
+}
+
+// report adds a finding for the rule, unless the rule is disabled.
+func (l *linter) report(ruleID string, line int, format string, args ...interface{}) {
+	for _, rule := range LintRules {
+		if rule.ID != ruleID {
+			continue
+		}
+		severity := l.config.severity(rule)
+		if severity == SeverityOff {
+			return
+		}
+		l.findings = append(l.findings, &LintFinding{
+			Rule:     rule.ID,
+			Severity: severity,
+			Line:     line,
+			Message:  fmt.Sprintf(format, args...),
+		})
+		return
+	}
+}
+
+func (l *linter) lint() []*LintFinding {
+	l.lintDuplicates()
+	l.lintVersions()
+	l.lintSubsections()
+	for _, diagnostic := range l.changelog.Diagnostics {
+		l.report(RuleUnparsedText, diagnostic.Line, "%s: %q", diagnostic.Reason, strings.TrimSpace(diagnostic.Text))
+	}
+	sort.SliceStable(l.findings, func(i, j int) bool {
+		return l.findings[i].Line < l.findings[j].Line
+	})
+	return l.findings
+}
+
+func (l *linter) lintDuplicates() {
+	if l.source == nil {
+		return
+	}
+	for _, duplicate := range l.source.duplicates {
+		if duplicate.subsectionName == "" {
+			l.report(RuleDuplicateVersion, duplicate.line, "version %s was already listed on line %d", duplicate.versionNum, duplicate.firstLine)
+		} else {
+			l.report(RuleDuplicateSubsection, duplicate.line, "subsection %q of version %s was already listed on line %d", duplicate.subsectionName, duplicate.versionNum, duplicate.firstLine)
+		}
+	}
+}
+
+// dateLikeRegexp matches text which looks like it is meant to be a date.
+var dateLikeRegexp = regexp.MustCompile(`\d+[-/. ]\d+|\d{8}`)
+
+func (l *linter) lintVersions() {
+	var previous, previousDated *Version
+	for _, version := range l.changelog.Versions {
+		if version.Version == "" || isUnreleased(version.Version) {
+			continue
+		}
+		line := l.source.line(version)
+
+		validDate := false
+		if version.Date != "" {
+			if _, err := time.Parse("2006-01-02", version.Date); err != nil {
+				l.report(RuleDateFormat, line, "version %s has an invalid date %q", version.Version, version.Date)
+			} else {
+				validDate = true
+			}
+		} else if l.source != nil {
+			if rest := dateLikeRegexp.FindString(afterVersion(l.source.headers[version], version.Version)); rest != "" {
+				l.report(RuleDateFormat, line, "version %s has a date which isn't formatted like 2006-01-02", version.Version)
+			}
+		}
+
+		if previousDated != nil && validDate && version.Date > previousDated.Date {
+			l.report(RuleDateOrder, line, "version %s (%s) is dated after version %s (%s) above it", version.Version, version.Date, previousDated.Version, previousDated.Date)
+		}
+		if previous != nil && version.Compare(previous) > 0 {
+			l.report(RuleVersionOrder, line, "version %s is higher than version %s above it", version.Version, previous.Version)
+		}
+
+		previous = version
+		if validDate {
+			previousDated = version
+		}
+	}
+}
+
+// afterVersion returns the part of the header after the version number.
+func afterVersion(header, versionNum string) string {
+	if i := strings.Index(header, versionNum); i >= 0 {
+		return header[i+len(versionNum):]
+	}
+	return ""
+}
+
+func (l *linter) lintSubsections() {
+	known := l.config.knownSubsections(l.changelog.dialect())
+	for _, version := range l.changelog.Versions {
+		l.lintChangeLines(version.History)
+		for _, subsection := range version.Subsections {
+			line := l.source.line(subsection)
+			if len(subsection.History) == 0 {
+				l.report(RuleEmptySubsection, line, "subsection %q of version %s has no changes", subsection.Name, version.Version)
+			}
+			if !containsFold(known, subsection.Name) {
+				l.report(RuleUnknownSubsection, line, "subsection %q isn't one of %s", subsection.Name, strings.Join(known, ", "))
+			}
+			l.lintChangeLines(subsection.History)
+		}
+	}
+}
+
+func (l *linter) lintChangeLines(lines []*ChangeLine) {
+	for _, line := range lines {
+		if line.Reference == "" {
+			l.report(RuleMissingReference, l.source.line(line), "change %q has no reference", firstLine(line.Summary))
+		}
+	}
+}
+
+// knownSubsections returns the subsection names which are known for the
+// dialect.
+func (config *LintConfig) knownSubsections(d Dialect) []string {
+	switch {
+	case config != nil && len(config.Subsections) > 0:
+		return config.Subsections
+	case d == KeepAChangelog:
+		return KeepAChangelogSubsections
+	}
+	return jekyllSubsections
+}
+
+// containsFold checks whether the list contains the string, ignoring case.
+func containsFold(list []string, str string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, str) {
+			return true
+		}
+	}
+	return false
+}
+
+// firstLine returns the first line of the text.
+func firstLine(text string) string {
+	if i := strings.Index(text, "\n"); i >= 0 {
+		return text[:i]
+	}
+	return text
+}
@@ -0,0 +1,92 @@
+package changelog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func findingStrings(findings []*LintFinding) []string {
+	strs := make([]string, len(findings))
+	for i, finding := range findings {
+		strs[i] = finding.String()
+	}
+	return strs
+}
+
+func TestLintFile(t *testing.T) {
+	findings, err := LintFile("testdata/lint.md", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		`line 9: error: subsection "Bug Fixes" of version HEAD was already listed on line 5 (duplicate-subsection)`,
+		`line 11: info: change "Fix the parser" has no reference (missing-reference)`,
+		`line 15: warning: subsection "Major Enhancements" of version 1.1.0 has no changes (empty-subsection)`,
+		`line 17: warning: subsection "Internals" isn't one of Major Enhancements, Minor Enhancements, Bug Fixes, Development Fixes, Site Enhancements, Documentation (unknown-subsection)`,
+		`line 21: warning: version 1.2.0 (2015-02-20) is dated after version 1.1.0 (2015-02-19) above it (date-order)`,
+		`line 21: warning: version 1.2.0 is higher than version 1.1.0 above it (version-order)`,
+		`line 25: warning: unrecognized header: "#### Notes!" (unparsed-text)`,
+		`line 27: error: version 1.0.0 has a date which isn't formatted like 2006-01-02 (date-format)`,
+		`line 35: error: version 0.2.0 was already listed on line 31 (duplicate-version)`,
+		`line 39: error: version 0.1.0 has an invalid date "2015-13-01" (date-format)`,
+	}, findingStrings(findings))
+}
+
+func TestLintReader_Clean(t *testing.T) {
+	for _, filename := range []string{"testdata/keep-a-changelog.md", "History.markdown"} {
+		findings, err := LintFile(filename, &LintConfig{Severities: map[string]Severity{RuleMissingReference: SeverityOff}})
+		assert.NoError(t, err)
+		for _, finding := range findings {
+			assert.NotEqual(t, SeverityError, finding.Severity, "%s: %s", filename, finding)
+		}
+	}
+}
+
+func TestLintConfig(t *testing.T) {
+	config := &LintConfig{
+		Severities: map[string]Severity{
+			RuleMissingReference:  SeverityOff,
+			RuleEmptySubsection:   SeverityError,
+			RuleUnknownSubsection: SeverityInfo,
+		},
+		Subsections: []string{"Bug Fixes", "Major Enhancements"},
+	}
+	findings, err := LintReader(strings.NewReader(`## HEAD
+
+### Bug Fixes
+
+  * Fix the parser
+
+### major enhancements
+
+### Internals
+
+  * Rewrite the tokenizer
+`), config)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		`line 7: error: subsection "major enhancements" of version HEAD has no changes (empty-subsection)`,
+		`line 9: info: subsection "Internals" isn't one of Bug Fixes, Major Enhancements (unknown-subsection)`,
+	}, findingStrings(findings))
+}
+
+func TestChangelogLint(t *testing.T) {
+	history := NewChangelog()
+	history.AddLineToSubsection("1.0.0", Added, &ChangeLine{Summary: "Initial release", Reference: "#1"})
+	history.SetDialect(KeepAChangelog)
+	history.GetVersionOrCreate("1.0.0").Date = "2015/02/20"
+
+	assert.Equal(t, []string{
+		`error: version 1.0.0 has an invalid date "2015/02/20" (date-format)`,
+	}, findingStrings(history.Lint(nil)))
+}
+
+func TestSeverityByName(t *testing.T) {
+	for s := SeverityOff; s <= SeverityError; s++ {
+		severity, err := SeverityByName(strings.ToUpper(s.String()))
+		assert.NoError(t, err)
+		assert.Equal(t, s, severity)
+	}
+	_, err := SeverityByName("fatal")
+	assert.EqualError(t, err, `unknown severity "fatal"`)
+}
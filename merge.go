@@ -0,0 +1,517 @@
+package changelog
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MergeConflict is a part of a changelog which both sides of a merge
+// changed in different ways.
+type MergeConflict struct {
+	// Version is the version the conflict is in, or empty for the preamble
+	// and the epilogue.
+	Version string
+	// Subsection is the subsection the conflict is in, if any.
+	Subsection string
+	// Field is what was changed: "preamble", "epilogue", "date", "yanked",
+	// "url", "description" or "changes".
+	Field string
+	// Base, Ours and Theirs are the conflicting values. For changes, they
+	// hold the lines which were replaced and the lines each side replaced
+	// them with, one per line.
+	Base, Ours, Theirs string
+}
+
+// String describes the conflict, e.g. `version 2.0.0: date changed to
+// "2015-03-02" by ours and to "2015-03-03" by theirs (was "")`.
+func (c *MergeConflict) String() string {
+	location := "changelog"
+	if c.Version != "" {
+		location = "version " + c.Version
+	}
+	if c.Subsection != "" {
+		location += ", subsection " + c.Subsection
+	}
+	return fmt.Sprintf("%s: %s changed to %q by ours and to %q by theirs (was %q)",
+		location, c.Field, c.Ours, c.Theirs, c.Base)
+}
+
+// merger holds the conflicts found while merging.
+type merger struct {
+	conflicts []*MergeConflict
+	// preferTheirs resolves the conflicts in favor of theirs instead.
+	preferTheirs bool
+}
+
+// Merge does a three-way merge of two changelogs which were both changed
+// from base, e.g. when a release branch is merged back. Versions are
+// matched by their version number, subsections by name, and the changes
+// of each are merged line by line: the lines added on either side are
+// kept, and the lines removed on either side are removed. This way, the
+// changes released on one side leave the unreleased changes, while the
+// ones added on the other side stay there.
+//
+// Parts which both sides changed in different ways, like the date of a
+// version or the same change line, are conflicts. They are resolved in
+// favor of ours, and returned so that they can be reviewed. The dialect
+// and style of the merged changelog are the ones of ours. None of the
+// changelogs are modified.
+func Merge(base, ours, theirs *Changelog) (*Changelog, []*MergeConflict) {
+	m := &merger{}
+	merged := m.merge(base, ours, theirs)
+	return merged, m.conflicts
+}
+
+// MergeWithMarkers merges the changelogs like Merge, and returns the
+// merged changelog as markdown in which the conflicts are marked the way
+// git marks them: the lines of ours come between "<<<<<<< ours" and
+// "=======", and the lines of theirs between "=======" and
+// ">>>>>>> theirs". Without conflicts, this is the merged changelog.
+func MergeWithMarkers(base, ours, theirs *Changelog) (string, []*MergeConflict) {
+	merged, conflicts := Merge(base, ours, theirs)
+	if len(conflicts) == 0 {
+		return merged.String(), nil
+	}
+	theirsMerged := (&merger{preferTheirs: true}).merge(base, ours, theirs)
+	eol := ""
+	if merged.style().LineEnding == "\r\n" {
+		eol = "\r"
+	}
+	return markConflicts(merged.String(), theirsMerged.String(), eol), conflicts
+}
+
+func (m *merger) merge(base, ours, theirs *Changelog) *Changelog {
+	merged := &Changelog{
+		Preamble: m.mergeString(base.Preamble, ours.Preamble, theirs.Preamble, &MergeConflict{Field: "preamble"}),
+		Versions: []*Version{},
+		Epilogue: m.mergeString(base.Epilogue, ours.Epilogue, theirs.Epilogue, &MergeConflict{Field: "epilogue"}),
+		Dialect:  ours.Dialect,
+	}
+	if ours.Style != nil {
+		style := *ours.Style
+		merged.Style = &style
+	}
+
+	baseVersions := versionsByKey(base)
+	oursVersions := versionsByKey(ours)
+	theirsVersions := versionsByKey(theirs)
+	for _, version := range ours.sortedVersions() {
+		key := mergeVersionKey(version)
+		if v := m.mergeVersion(baseVersions[key], version, theirsVersions[key]); v != nil {
+			merged.appendVersion(v)
+		}
+	}
+	for _, version := range theirs.sortedVersions() {
+		key := mergeVersionKey(version)
+		if _, ok := oursVersions[key]; ok {
+			continue
+		}
+		if v := m.mergeVersion(baseVersions[key], nil, version); v != nil {
+			merged.insertVersion(v)
+		}
+	}
+	return merged
+}
+
+// conflictContext is the number of lines which have to be the same again
+// after a conflict for it to end.
+const conflictContext = 3
+
+// markConflicts puts conflict markers around the lines in which the
+// changelog resolved in favor of ours differs from the one resolved in
+// favor of theirs. As both are written from the same merge, they only
+// differ where there were conflicts. eol is added to the markers before
+// the newline.
+func markConflicts(ours, theirs, eol string) string {
+	a, b := strings.Split(ours, "\n"), strings.Split(theirs, "\n")
+	sameFrom := func(i, j int) bool {
+		for n := 0; n < conflictContext; n++ {
+			if i+n == len(a) || j+n == len(b) {
+				return i+n == len(a) && j+n == len(b)
+			}
+			if a[i+n] != b[j+n] {
+				return false
+			}
+		}
+		return true
+	}
+
+	lines := []string{}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		if i < len(a) && j < len(b) && a[i] == b[j] {
+			lines = append(lines, a[i])
+			i, j = i+1, j+1
+			continue
+		}
+		// Find the nearest lines from which both are the same again.
+		nextI, nextJ := len(a), len(b)
+	search:
+		for distance := 1; distance < len(a)-i+len(b)-j; distance++ {
+			for k := 0; k <= distance; k++ {
+				if i+k <= len(a) && j+distance-k <= len(b) && sameFrom(i+k, j+distance-k) {
+					nextI, nextJ = i+k, j+distance-k
+					break search
+				}
+			}
+		}
+		lines = append(lines, "<<<<<<< ours"+eol)
+		lines = append(lines, a[i:nextI]...)
+		lines = append(lines, "======="+eol)
+		lines = append(lines, b[j:nextJ]...)
+		lines = append(lines, ">>>>>>> theirs"+eol)
+		i, j = nextI, nextJ
+	}
+	return strings.Join(lines, "\n")
+}
+
+// mergeVersionKey identifies a version across changelogs, so that e.g.
+// "HEAD" matches "[Unreleased]" and "[1.0.0]" matches "1.0.0".
+func mergeVersionKey(v *Version) string {
+	if v.Kind() != VersionKindRelease {
+		return v.Kind()
+	}
+	return strings.ToLower(strings.Trim(strings.TrimSpace(v.Version), "[]"))
+}
+
+func versionsByKey(c *Changelog) map[string]*Version {
+	versions := map[string]*Version{}
+	for _, version := range c.Versions {
+		key := mergeVersionKey(version)
+		if _, ok := versions[key]; !ok {
+			versions[key] = version
+		}
+	}
+	return versions
+}
+
+// mergeVersion merges the version. Any of them can be nil if the version
+// isn't in that changelog. A version which is left empty after one side
+// removed it is dropped, by returning nil.
+func (m *merger) mergeVersion(base, ours, theirs *Version) *Version {
+	if base == nil && (ours == nil || theirs == nil) {
+		// Added on one side only.
+		added := ours
+		if added == nil {
+			added = theirs
+		}
+		copied := copyVersion(added)
+		copied.sortOrder = NewVersion(added.Version).sortOrder
+		return copied
+	}
+	removed := ours == nil || theirs == nil
+	var merged *Version
+	switch {
+	case ours != nil:
+		merged = NewVersion(ours.Version)
+	case theirs != nil:
+		merged = NewVersion(theirs.Version)
+	default:
+		merged = NewVersion(base.Version)
+	}
+	if base == nil {
+		base = &Version{}
+	}
+	if ours == nil {
+		ours = removedVersion(base)
+	}
+	if theirs == nil {
+		theirs = removedVersion(base)
+	}
+
+	conflict := func(field string) *MergeConflict {
+		return &MergeConflict{Version: merged.Version, Field: field}
+	}
+	merged.Date = m.mergeString(base.Date, ours.Date, theirs.Date, conflict("date"))
+	merged.Yanked = m.mergeString(fmt.Sprint(base.Yanked), fmt.Sprint(ours.Yanked), fmt.Sprint(theirs.Yanked), conflict("yanked")) == "true"
+	merged.URL = m.mergeString(base.URL, ours.URL, theirs.URL, conflict("url"))
+	merged.urlLabel = ours.urlLabel
+	if merged.urlLabel == "" {
+		merged.urlLabel = theirs.urlLabel
+	}
+	merged.Description = m.mergeString(base.Description, ours.Description, theirs.Description, conflict("description"))
+	merged.History = m.mergeChangeLines(base.History, ours.History, theirs.History, conflict("changes"))
+	merged.Subsections = m.mergeSubsections(merged.Version, base.Subsections, ours.Subsections, theirs.Subsections)
+
+	if removed && merged.Description == "" && len(merged.History) == 0 && len(merged.Subsections) == 0 {
+		return nil
+	}
+	return merged
+}
+
+// removedVersion stands in for a version which one side removed: its
+// details are the ones of base, so that they don't conflict, but it has
+// no changes.
+func removedVersion(base *Version) *Version {
+	return &Version{
+		Date:        base.Date,
+		Yanked:      base.Yanked,
+		URL:         base.URL,
+		Description: base.Description,
+	}
+}
+
+func copyVersion(v *Version) *Version {
+	copied := *v
+	copied.History = copyChangeLines(v.History)
+	copied.Subsections = make([]*Subsection, len(v.Subsections))
+	for i, subsection := range v.Subsections {
+		copied.Subsections[i] = copySubsection(subsection)
+	}
+	return &copied
+}
+
+func copySubsection(s *Subsection) *Subsection {
+	copied := *s
+	copied.History = copyChangeLines(s.History)
+	return &copied
+}
+
+func copyChangeLines(lines []*ChangeLine) []*ChangeLine {
+	copied := make([]*ChangeLine, len(lines))
+	for i, line := range lines {
+		copiedLine := *line
+		copied[i] = &copiedLine
+	}
+	return copied
+}
+
+// mergeSubsections merges the subsections of a version, in the order of
+// ours, followed by the ones only theirs has.
+func (m *merger) mergeSubsections(versionNum string, base, ours, theirs []*Subsection) []*Subsection {
+	find := func(subsections []*Subsection, name string) *Subsection {
+		for _, subsection := range subsections {
+			if strings.EqualFold(strings.TrimSpace(subsection.Name), strings.TrimSpace(name)) {
+				return subsection
+			}
+		}
+		return nil
+	}
+
+	merged := []*Subsection{}
+	for _, subsection := range ours {
+		if s := m.mergeSubsection(versionNum, find(base, subsection.Name), subsection, find(theirs, subsection.Name)); s != nil {
+			merged = append(merged, s)
+		}
+	}
+	for _, subsection := range theirs {
+		if find(ours, subsection.Name) != nil {
+			continue
+		}
+		if s := m.mergeSubsection(versionNum, find(base, subsection.Name), nil, subsection); s != nil {
+			merged = append(merged, s)
+		}
+	}
+	return merged
+}
+
+// mergeSubsection merges the subsection like mergeVersion merges a
+// version.
+func (m *merger) mergeSubsection(versionNum string, base, ours, theirs *Subsection) *Subsection {
+	if base == nil && (ours == nil || theirs == nil) {
+		if ours != nil {
+			return copySubsection(ours)
+		}
+		return copySubsection(theirs)
+	}
+	removed := ours == nil || theirs == nil
+	if base == nil {
+		base = &Subsection{}
+	}
+	if ours == nil {
+		ours = &Subsection{Name: theirs.Name, Description: base.Description}
+	}
+	if theirs == nil {
+		theirs = &Subsection{Name: ours.Name, Description: base.Description}
+	}
+
+	conflict := func(field string) *MergeConflict {
+		return &MergeConflict{Version: versionNum, Subsection: ours.Name, Field: field}
+	}
+	merged := &Subsection{
+		Name:        ours.Name,
+		Description: m.mergeString(base.Description, ours.Description, theirs.Description, conflict("description")),
+		History:     m.mergeChangeLines(base.History, ours.History, theirs.History, conflict("changes")),
+	}
+	if removed && merged.Description == "" && len(merged.History) == 0 {
+		return nil
+	}
+	return merged
+}
+
+// mergeString merges a value which is replaced as a whole. If both sides
+// changed it in different ways, the conflict is recorded and ours is
+// kept.
+func (m *merger) mergeString(base, ours, theirs string, conflict *MergeConflict) string {
+	switch {
+	case ours == theirs, theirs == base:
+		return ours
+	case ours == base:
+		return theirs
+	}
+	conflict.Base, conflict.Ours, conflict.Theirs = base, ours, theirs
+	m.conflicts = append(m.conflicts, conflict)
+	if m.preferTheirs {
+		return theirs
+	}
+	return ours
+}
+
+// changeLineKey identifies a change line when comparing lists of changes.
+func changeLineKey(l *ChangeLine) string {
+	return strings.TrimSpace(l.Summary) + "\x00" + strings.TrimSpace(l.Reference)
+}
+
+// mergeChangeLines does a three-way merge of the lists of changes: the
+// lines which are in all three lists anchor the merge, and the stretches
+// between them are merged with mergeChangeLineChunks.
+func (m *merger) mergeChangeLines(base, ours, theirs []*ChangeLine, conflict *MergeConflict) []*ChangeLine {
+	oursMatches := matchChangeLines(base, ours)
+	theirsMatches := matchChangeLines(base, theirs)
+
+	merged := []*ChangeLine{}
+	i, j, k := 0, 0, 0
+	for {
+		next := i
+		for next < len(base) && (oursMatches[next] < 0 || theirsMatches[next] < 0) {
+			next++
+		}
+		nextOurs, nextTheirs := len(ours), len(theirs)
+		if next < len(base) {
+			nextOurs, nextTheirs = oursMatches[next], theirsMatches[next]
+		}
+		merged = append(merged, m.mergeChangeLineChunks(base[i:next], ours[j:nextOurs], theirs[k:nextTheirs], conflict)...)
+		if next == len(base) {
+			break
+		}
+		merged = append(merged, ours[nextOurs])
+		i, j, k = next+1, nextOurs+1, nextTheirs+1
+	}
+	return copyChangeLines(merged)
+}
+
+// matchChangeLines finds the longest common subsequence of the two lists
+// of changes. It returns, for each line of a, the index of the matching
+// line of b, or -1.
+func matchChangeLines(a, b []*ChangeLine) []int {
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case changeLineKey(a[i]) == changeLineKey(b[j]):
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			case lengths[i+1][j] >= lengths[i][j+1]:
+				lengths[i][j] = lengths[i+1][j]
+			default:
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+
+	matches := make([]int, len(a))
+	i, j := 0, 0
+	for i < len(a) {
+		switch {
+		case j < len(b) && changeLineKey(a[i]) == changeLineKey(b[j]):
+			matches[i] = j
+			i++
+			j++
+		case j < len(b) && lengths[i+1][j] < lengths[i][j+1]:
+			j++
+		default:
+			matches[i] = -1
+			i++
+		}
+	}
+	return matches
+}
+
+// mergeChangeLineChunks merges a stretch of changes which at least one
+// side changed. Both sides' new lines are kept and the lines either side
+// removed are removed, unless both sides replaced the same lines with
+// different ones: that's a conflict, and ours is kept.
+func (m *merger) mergeChangeLineChunks(base, ours, theirs []*ChangeLine, conflict *MergeConflict) []*ChangeLine {
+	switch {
+	case sameChangeLines(ours, theirs), sameChangeLines(theirs, base):
+		return ours
+	case sameChangeLines(ours, base):
+		return theirs
+	}
+
+	inBase := changeLineKeys(base)
+	inOurs := changeLineKeys(ours)
+	inTheirs := changeLineKeys(theirs)
+	removedByBoth := []*ChangeLine{}
+	for _, line := range base {
+		if !inOurs[changeLineKey(line)] && !inTheirs[changeLineKey(line)] {
+			removedByBoth = append(removedByBoth, line)
+		}
+	}
+	addedByOurs := changeLinesNotIn(ours, inBase)
+	addedByTheirs := changeLinesNotIn(theirs, inBase)
+	if len(removedByBoth) > 0 && len(addedByOurs) > 0 && len(addedByTheirs) > 0 &&
+		!sameChangeLines(addedByOurs, addedByTheirs) {
+		conflict := *conflict
+		conflict.Base = changeLinesString(removedByBoth)
+		conflict.Ours = changeLinesString(addedByOurs)
+		conflict.Theirs = changeLinesString(addedByTheirs)
+		m.conflicts = append(m.conflicts, &conflict)
+		if m.preferTheirs {
+			return theirs
+		}
+		return ours
+	}
+
+	merged := []*ChangeLine{}
+	for _, line := range ours {
+		key := changeLineKey(line)
+		if !inBase[key] || inTheirs[key] {
+			merged = append(merged, line)
+		}
+	}
+	return append(merged, changeLinesNotIn(addedByTheirs, inOurs)...)
+}
+
+func sameChangeLines(a, b []*ChangeLine) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if changeLineKey(a[i]) != changeLineKey(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func changeLineKeys(lines []*ChangeLine) map[string]bool {
+	keys := map[string]bool{}
+	for _, line := range lines {
+		keys[changeLineKey(line)] = true
+	}
+	return keys
+}
+
+func changeLinesNotIn(lines []*ChangeLine, keys map[string]bool) []*ChangeLine {
+	notIn := []*ChangeLine{}
+	for _, line := range lines {
+		if !keys[changeLineKey(line)] {
+			notIn = append(notIn, line)
+		}
+	}
+	return notIn
+}
+
+// changeLinesString lists the changes one per line, as they are written
+// in the Jekyll dialect without indentation.
+func changeLinesString(lines []*ChangeLine) string {
+	style := &Style{Bullet: "*"}
+	strs := make([]string, len(lines))
+	for i, line := range lines {
+		strs[i] = formatChangeLine(line, style)
+	}
+	return strings.Join(strs, "\n")
+}
@@ -0,0 +1,326 @@
+package changelog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func parseMarkdown(t *testing.T, markdown string) *Changelog {
+	history, err := NewChangelogFromReader(strings.NewReader(markdown))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return history
+}
+
+func TestMerge_UnionsNewLines(t *testing.T) {
+	base := parseMarkdown(t, `## HEAD
+
+  * First change (#1)
+
+## 1.0.0 / 2015-02-20
+
+  * Initial release
+`)
+	ours := parseMarkdown(t, `## HEAD
+
+  * First change (#1)
+  * Our change (#2)
+
+## 1.0.0 / 2015-02-20
+
+  * Initial release
+`)
+	theirs := parseMarkdown(t, `## HEAD
+
+  * First change (#1)
+  * Their change (#3)
+
+### Bug Fixes
+
+  * Their fix (#4)
+
+## 1.0.0 / 2015-02-20
+
+  * Initial release
+`)
+
+	merged, conflicts := Merge(base, ours, theirs)
+
+	assert.Empty(t, conflicts)
+	assert.Equal(t, `## HEAD
+
+  * First change (#1)
+  * Our change (#2)
+  * Their change (#3)
+
+### Bug Fixes
+
+  * Their fix (#4)
+
+## 1.0.0 / 2015-02-20
+
+  * Initial release
+`, merged.String())
+	assert.Len(t, ours.GetVersion("HEAD").History, 2, "ours is left alone")
+}
+
+func TestMerge_ReleaseOnOneSide(t *testing.T) {
+	base := parseMarkdown(t, `## HEAD
+
+### Bug Fixes
+
+  * Fix the tokenizer (#1)
+
+## 1.0.0 / 2015-02-20
+
+  * Initial release
+`)
+	ours := parseMarkdown(t, `## HEAD
+
+### Bug Fixes
+
+  * Fix the tokenizer (#1)
+  * Fix the parser (#2)
+
+## 1.0.0 / 2015-02-20
+
+  * Initial release
+`)
+	theirs := parseMarkdown(t, `## 1.0.1 / 2015-03-01
+
+### Bug Fixes
+
+  * Fix the tokenizer (#1)
+
+## 1.0.0 / 2015-02-20
+
+  * Initial release
+`)
+
+	merged, conflicts := Merge(base, ours, theirs)
+
+	assert.Empty(t, conflicts)
+	assert.Equal(t, `## HEAD
+
+### Bug Fixes
+
+  * Fix the parser (#2)
+
+## 1.0.1 / 2015-03-01
+
+### Bug Fixes
+
+  * Fix the tokenizer (#1)
+
+## 1.0.0 / 2015-02-20
+
+  * Initial release
+`, merged.String())
+
+	// The other way around, the unreleased changes are gone on both sides.
+	merged, conflicts = Merge(base, theirs, base)
+	assert.Empty(t, conflicts)
+	assert.Nil(t, merged.GetUnreleased())
+}
+
+func TestMerge_RemovedLines(t *testing.T) {
+	base := parseMarkdown(t, "## HEAD\n\n  * One\n  * Two\n  * Three\n")
+	ours := parseMarkdown(t, "## HEAD\n\n  * One\n  * Three\n  * Four\n")
+	theirs := parseMarkdown(t, "## HEAD\n\n  * Zero\n  * One\n  * Two\n")
+
+	merged, conflicts := Merge(base, ours, theirs)
+
+	assert.Empty(t, conflicts)
+	assert.Equal(t, "## HEAD\n\n  * Zero\n  * One\n  * Four\n", merged.String())
+}
+
+func TestMerge_Conflicts(t *testing.T) {
+	base := parseMarkdown(t, `# Changelog
+
+## HEAD
+
+### Bug Fixes
+
+  * Fix the tokenizer (#1)
+
+## 1.0.0
+
+  * Initial release
+`)
+	ours := parseMarkdown(t, `# Our Changelog
+
+## HEAD
+
+### Bug Fixes
+
+  * Fix the tokenizer again (#1)
+
+## 1.0.0 / 2015-02-20
+
+  * Initial release
+`)
+	theirs := parseMarkdown(t, `# Their Changelog
+
+## HEAD
+
+### Bug Fixes
+
+  * Fix the tokenizer (#1, #5)
+
+## 1.0.0 / 2015-02-21
+
+  * Initial release
+`)
+
+	merged, conflicts := Merge(base, ours, theirs)
+
+	assert.Equal(t, ours.String(), merged.String())
+	assert.Equal(t, []*MergeConflict{
+		{Field: "preamble", Base: "# Changelog", Ours: "# Our Changelog", Theirs: "# Their Changelog"},
+		{Version: "HEAD", Subsection: "Bug Fixes", Field: "changes",
+			Base: "* Fix the tokenizer (#1)", Ours: "* Fix the tokenizer again (#1)", Theirs: "* Fix the tokenizer (#1, #5)"},
+		{Version: "1.0.0", Field: "date", Base: "", Ours: "2015-02-20", Theirs: "2015-02-21"},
+	}, conflicts)
+	assert.Equal(t, `version 1.0.0: date changed to "2015-02-20" by ours and to "2015-02-21" by theirs (was "")`, conflicts[2].String())
+	assert.Equal(t, `changelog: preamble changed to "# Our Changelog" by ours and to "# Their Changelog" by theirs (was "# Changelog")`, conflicts[0].String())
+}
+
+func TestMergeWithMarkers(t *testing.T) {
+	base := parseMarkdown(t, `# Changelog
+
+## HEAD
+
+### Bug Fixes
+
+  * Fix the tokenizer (#1)
+  * Fix the parser (#2)
+
+## 1.0.0
+
+  * Initial release
+`)
+	ours := parseMarkdown(t, `# Changelog
+
+## HEAD
+
+### Bug Fixes
+
+  * Fix the tokenizer again (#1)
+  * Fix the parser (#2)
+
+## 1.0.0 / 2015-02-20
+
+  * Initial release
+`)
+	theirs := parseMarkdown(t, `# Changelog
+
+## HEAD
+
+### Bug Fixes
+
+  * Fix the tokenizer (#1, #5)
+  * Fix the tokenizer twice (#6)
+  * Fix the parser (#2)
+  * Fix the linter (#7)
+
+## 1.0.0 / 2015-02-21
+
+  * Initial release
+`)
+
+	merged, conflicts := MergeWithMarkers(base, ours, theirs)
+
+	assert.Len(t, conflicts, 2)
+	assert.Equal(t, `# Changelog
+
+## HEAD
+
+### Bug Fixes
+
+<<<<<<< ours
+  * Fix the tokenizer again (#1)
+=======
+  * Fix the tokenizer (#1, #5)
+  * Fix the tokenizer twice (#6)
+>>>>>>> theirs
+  * Fix the parser (#2)
+  * Fix the linter (#7)
+
+<<<<<<< ours
+## 1.0.0 / 2015-02-20
+=======
+## 1.0.0 / 2015-02-21
+>>>>>>> theirs
+
+  * Initial release
+`, merged)
+
+	merged, conflicts = MergeWithMarkers(base, ours, ours)
+	assert.Empty(t, conflicts)
+	assert.Equal(t, ours.String(), merged)
+}
+
+func TestMerge_CopiesStyle(t *testing.T) {
+	base := parseMarkdown(t, "## HEAD\n\n- One\n")
+
+	merged, _ := Merge(base, base, base)
+	merged.Style.Bullet = "*"
+
+	assert.Equal(t, "-", base.Style.Bullet)
+}
+
+func TestMerge_SameChangeOnBothSides(t *testing.T) {
+	base := parseMarkdown(t, "## HEAD\n\n  * One\n")
+	ours := parseMarkdown(t, "## HEAD\n\n  * One (#1)\n\n## 1.0.0 / 2015-02-20\n\n  * Initial release\n")
+	theirs := parseMarkdown(t, "## HEAD\n\n  * One (#1)\n\n## 1.0.0 / 2015-02-20\n\n  * Initial release\n")
+
+	merged, conflicts := Merge(base, ours, theirs)
+
+	assert.Empty(t, conflicts)
+	assert.Equal(t, ours.String(), merged.String())
+}
+
+func TestMerge_DialectsAndVersionsAdded(t *testing.T) {
+	base := parseKeepAChangelog(t)
+	ours := parseKeepAChangelog(t)
+	theirs := parseKeepAChangelog(t)
+	ours.GetSubsectionOrCreate("[Unreleased]", Fixed).History = []*ChangeLine{{Summary: "Our fix"}}
+	theirs.GetVersionOrCreate("1.2.0").Date = "2024-01-01"
+	theirs.GetVersion("1.2.0").History = []*ChangeLine{{Summary: "Their release"}}
+
+	merged, conflicts := Merge(base, ours, theirs)
+
+	assert.Empty(t, conflicts)
+	assert.Equal(t, KeepAChangelog, merged.Dialect)
+	assert.True(t, merged.HasLine("[Unreleased]", Fixed, &ChangeLine{Summary: "Our fix"}))
+	assert.Equal(t, []string{"[Unreleased]", "1.2.0", "1.1.1", "1.1.0", "0.0.5", "0.0.1"}, versionNumbers(merged))
+	assert.Contains(t, merged.String(), "\n## [1.2.0] - 2024-01-01\n\n- Their release\n\n## [1.1.1] - 2023-03-05\n")
+}
+
+func versionNumbers(c *Changelog) []string {
+	numbers := make([]string, len(c.Versions))
+	for i, v := range c.Versions {
+		numbers[i] = v.Version
+	}
+	return numbers
+}
+
+func TestMerge_LeavesChangelogsAlone(t *testing.T) {
+	unsorted := func() *Changelog {
+		history := NewChangelog()
+		history.Versions = []*Version{NewVersion("1.0.0"), NewVersion("HEAD")}
+		return history
+	}
+	base, ours, theirs := unsorted(), unsorted(), unsorted()
+
+	merged, _ := Merge(base, ours, theirs)
+	Diff(ours, theirs)
+
+	assert.Equal(t, []string{"HEAD", "1.0.0"}, versionNumbers(merged))
+	for _, history := range []*Changelog{base, ours, theirs} {
+		assert.Equal(t, []string{"1.0.0", "HEAD"}, versionNumbers(history))
+	}
+}
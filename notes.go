@@ -0,0 +1,65 @@
+package changelog
+
+import (
+	"strings"
+)
+
+// FormatVersion returns the markdown for the version in the dialect and
+// style of the changelog. If header is false, the version header is left
+// out, e.g. for the notes of a release on GitHub.
+func (c *Changelog) FormatVersion(v *Version, header bool) string {
+	if header {
+		return v.format(c.dialect(), c.style())
+	}
+	return v.formatBody(c.dialect(), c.style())
+}
+
+// Text returns a plain text representation of the version, without
+// markdown headers: the version and its subsections are written as titles,
+// and the changes as a list. The changes and descriptions are kept as they
+// are, so any inline markdown in them, like links or code, remains. If
+// header is false, the version and its date are left out.
+//
+//	4.1.0 (2026-01-02)
+//
+//	Bug Fixes:
+//
+//	- Fix the tokenizer (#123)
+func (v *Version) Text(header bool) string {
+	blocks := []string{}
+	if header && v.Version != "" {
+		title := v.Version
+		if v.Date != "" {
+			title += " (" + v.Date + ")"
+		}
+		if v.Yanked {
+			title += " [YANKED]"
+		}
+		blocks = append(blocks, title)
+	}
+	if v.Description != "" {
+		blocks = append(blocks, v.Description)
+	}
+	if len(v.History) > 0 {
+		blocks = append(blocks, changeLinesText(v.History))
+	}
+	for _, subsection := range v.Subsections {
+		blocks = append(blocks, subsection.Name+":")
+		if subsection.Description != "" {
+			blocks = append(blocks, subsection.Description)
+		}
+		if len(subsection.History) > 0 {
+			blocks = append(blocks, changeLinesText(subsection.History))
+		}
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+func changeLinesText(lines []*ChangeLine) string {
+	style := &Style{Bullet: "-"}
+	strs := make([]string, len(lines))
+	for i, line := range lines {
+		strs[i] = formatChangeLine(line, style)
+	}
+	return strings.Join(strs, "\n")
+}
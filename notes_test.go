@@ -0,0 +1,46 @@
+package changelog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func notesVersion() *Version {
+	version := NewVersion("4.1.0")
+	version.Date = "2026-01-02"
+	version.History = []*ChangeLine{{Summary: "Add a show command", Reference: "#100"}}
+	version.Subsections = []*Subsection{{
+		Name:    "Bug Fixes",
+		History: []*ChangeLine{{Summary: "Fix the tokenizer", Reference: "@parkr"}},
+	}}
+	return version
+}
+
+func TestFormatVersion(t *testing.T) {
+	history := parseKeepAChangelog(t)
+	version := history.GetVersion("1.1.1")
+
+	actual := history.FormatVersion(version, true)
+	assert.Contains(t, actual, "## [1.1.1] - 2023-03-05\n\n### Added\n\n- Arabic translation (#444).\n")
+
+	actual = history.FormatVersion(version, false)
+	assert.Contains(t, actual, "### Added\n\n- Arabic translation (#444).\n")
+	assert.NotContains(t, actual, "1.1.1")
+}
+
+func TestVersionText(t *testing.T) {
+	version := notesVersion()
+	assert.Equal(t, `4.1.0 (2026-01-02)
+
+- Add a show command (#100)
+
+Bug Fixes:
+
+- Fix the tokenizer (@parkr)`, version.Text(true))
+	assert.Equal(t, `- Add a show command (#100)
+
+Bug Fixes:
+
+- Fix the tokenizer (@parkr)`, version.Text(false))
+}
@@ -2,6 +2,7 @@ package changelog
 
 import (
 	"bufio"
+	"fmt"
 	"io"
 	"log"
 	"regexp"
@@ -9,10 +10,10 @@ import (
 )
 
 var (
-	versionRegexp           = regexp.MustCompile(`##? \[?(?i:(\[UNRELEASED\]|HEAD|v?\d+\.\d+(?:\.\d+)?(?:[-+][\w+\-.]+)?))\]?.*?(\d{4}-\d{2}-\d{2})?.?$\z`)
-	subheaderRegexp         = regexp.MustCompile(`### ([0-9A-Za-z_ ]+)\z`)
-	changeLineRegexp        = regexp.MustCompile(`[\*|\-] (.+)\z`)
-	changeLineRegexpWithRef = regexp.MustCompile(`[\*|\-] (.+)( \(((#[0-9]+)|(@?[[:word:]]+))\))\z`)
+	versionRegexp       = regexp.MustCompile(`##? \[?(?i:(\[UNRELEASED\]|HEAD|v?\d+\.\d+(?:\.\d+)?(?:[-+][\w+\-.]+)?))\]?.*?(\d{4}-\d{2}-\d{2})?.?$\z`)
+	subheaderRegexp     = regexp.MustCompile(`### ([0-9A-Za-z_ ]+)\z`)
+	changeLineRegexp    = regexp.MustCompile(`[\*|\-] (.+)\z`)
+	linkReferenceRegexp = regexp.MustCompile(`^\[[^\]]+\]:\s*\S`)
 
 	verbose = false
 )
@@ -23,6 +24,50 @@ func SetVerbose(v bool) {
 	verbose = v
 }
 
+// ParseError describes a problem found on a given line of a changelog.
+//
+// When the changelog can't be read at all, a ParseError is returned as the
+// error from NewChangelogFromReader or NewChangelogFromFile. Problems the
+// parser is able to work around, like text it doesn't know where to put,
+// are collected in Changelog.Diagnostics instead.
+type ParseError struct {
+	// Line is the 1-based line number on which the problem was found.
+	Line int
+	// Column is the 1-based column at which the problem starts, or 0 if
+	// the problem applies to the whole line.
+	Column int
+	// Text is the offending line, if it could be read.
+	Text string
+	// Reason is a human-readable explanation of the problem.
+	Reason string
+	// Err is the underlying error, if any.
+	Err error
+}
+
+// Error returns the position and reason of the ParseError.
+func (e *ParseError) Error() string {
+	if e.Column > 0 {
+		return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Reason)
+	}
+	return fmt.Sprintf("line %d: %s", e.Line, e.Reason)
+}
+
+// Unwrap returns the underlying error, if any.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// newDiagnostic creates a ParseError for the given line, pointing at its
+// first non-whitespace character.
+func newDiagnostic(lineNum int, txt, reason string) *ParseError {
+	return &ParseError{
+		Line:   lineNum,
+		Column: lineIndent(txt) + 1,
+		Text:   txt,
+		Reason: reason,
+	}
+}
+
 func logVerbose(args ...interface{}) {
 	if verbose == true {
 		log.Println(args...)
@@ -48,68 +93,313 @@ func versionDateFromMatches(matches []string) string {
 	return date
 }
 
+// isChangeLine checks whether the line starts with a list marker. The
+// changeLineRegexp alone would also match a dash in the middle of a line of
+// prose.
+func isChangeLine(txt string) bool {
+	trimmed := strings.TrimLeft(txt, " \t")
+	return strings.HasPrefix(trimmed, "* ") || strings.HasPrefix(trimmed, "- ")
+}
+
+// lineIndent returns the length of the whitespace at the start of the line.
+func lineIndent(txt string) int {
+	return len(txt) - len(strings.TrimLeft(txt, " \t"))
+}
+
+// isNestedChangeLine checks whether the list item is nested in the change
+// before it, i.e. is indented deeper than that change, which is indented
+// by currentIndent. It is kept as a continuation of that change rather
+// than becoming a change of its own.
+func isNestedChangeLine(txt string, currentLine *ChangeLine, currentIndent int) bool {
+	return currentLine != nil && lineIndent(txt) > currentIndent
+}
+
+// isEpilogueStart checks whether the line can begin the trailing content
+// of a changelog, like a set of link reference definitions or a comment.
+func isEpilogueStart(txt string) bool {
+	return linkReferenceRegexp.MatchString(txt) || strings.HasPrefix(txt, "<!--")
+}
+
+// trimBlankLines removes leading and trailing blank lines.
+func trimBlankLines(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	return trimTrailingBlankLines(lines)
+}
+
+// trimTrailingBlankLines removes trailing blank lines.
+func trimTrailingBlankLines(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// blankLinesSeparator returns the separator made of the given number of
+// blank lines, e.g. "\n\n" for one.
+func blankLinesSeparator(blankLines int) string {
+	return strings.Repeat("\n", blankLines+1)
+}
+
+// appendParagraph adds text to a block of prose, separating it from the
+// existing prose with a blank line.
+func appendParagraph(prose, text string) string {
+	if prose == "" {
+		return text
+	}
+	return prose + "\n\n" + text
+}
+
+// sourceInfo records where the parts of a changelog were found in its
+// source, which the linter needs but the Changelog doesn't keep.
+type sourceInfo struct {
+	// lines maps each Version, Subsection and ChangeLine to the line it
+	// starts on.
+	lines map[interface{}]int
+	// headers maps each Version to the text of its header.
+	headers map[*Version]string
+	// duplicates lists the headers which repeat an earlier one, and were
+	// merged into it.
+	duplicates []*duplicateHeader
+}
+
+// duplicateHeader is a version or subsection header which repeats an
+// earlier one.
+type duplicateHeader struct {
+	line, firstLine int
+	versionNum      string
+	// subsectionName is empty for a version header.
+	subsectionName string
+}
+
+func newSourceInfo() *sourceInfo {
+	return &sourceInfo{lines: map[interface{}]int{}, headers: map[*Version]string{}}
+}
+
+// line returns the line on which the part of the changelog starts, or 0 if
+// it isn't known.
+func (s *sourceInfo) line(part interface{}) int {
+	if s == nil {
+		return 0
+	}
+	return s.lines[part]
+}
+
+// record remembers the line on which the part of the changelog starts. If
+// the part was already seen, the header on this line is a duplicate.
+func (s *sourceInfo) record(part interface{}, lineNum int, versionNum, subsectionName string) {
+	if s == nil {
+		return
+	}
+	if firstLine, ok := s.lines[part]; ok {
+		s.duplicates = append(s.duplicates, &duplicateHeader{
+			line:           lineNum,
+			firstLine:      firstLine,
+			versionNum:     versionNum,
+			subsectionName: subsectionName,
+		})
+		return
+	}
+	s.lines[part] = lineNum
+}
+
 func parseChangelog(file io.Reader, history *Changelog) error {
+	return parseChangelogSource(file, history, nil)
+}
+
+// parseChangelogSource parses the changelog into history. If source isn't
+// nil, it records where each part of the changelog was found.
+func parseChangelogSource(file io.Reader, history *Changelog, source *sourceInfo) error {
+	style := &Style{}
+	lineEndingDetected := false
 	scanner := bufio.NewScanner(file)
-	scanner.Split(bufio.ScanLines)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := bufio.ScanLines(data, atEOF)
+		// The line ending is detected from the first line which has one.
+		if !lineEndingDetected && token != nil && advance > len(token) {
+			lineEndingDetected = true
+			if advance == len(token)+2 {
+				style.LineEnding = "\r\n"
+			}
+		}
+		return advance, token, err
+	})
 
 	currentHeader := ""
 	currentSubHeader := ""
+	var currentVersion *Version
+	var currentSubsection *Subsection
 	var currentLine *ChangeLine
+	// currentIndent is the indent of currentLine.
+	currentIndent := 0
+
+	// Lines which aren't a header or a change line are held in pending
+	// until the next header or change line tells us where they belong.
+	// flushPending returns the separator which goes before the next part,
+	// e.g. "\n\n" if it comes after a single blank line.
+	var pending []string
+	flushPending := func() string {
+		lines := trimTrailingBlankLines(pending)
+		separator := blankLinesSeparator(len(pending) - len(lines))
+		if history.Preamble == "" && len(history.Versions) == 0 && len(lines) == 0 {
+			// Nothing comes before the next part, so it needs no separator.
+			separator = "\n\n"
+		}
+		pending = nil
+		if len(lines) == 0 {
+			return separator
+		}
+		trimmed := trimBlankLines(lines)
+		descriptionSeparator := blankLinesSeparator(len(lines) - len(trimmed))
+		if descriptionSeparator == "\n\n" {
+			descriptionSeparator = ""
+		}
+		switch {
+		case currentLine != nil:
+			currentLine.Summary += "\n" + strings.Join(lines, "\n")
+		case currentSubsection != nil:
+			if currentSubsection.Description == "" {
+				currentSubsection.descriptionSeparator = descriptionSeparator
+			}
+			currentSubsection.Description = appendParagraph(currentSubsection.Description, strings.Join(trimmed, "\n"))
+		case currentVersion != nil:
+			if currentVersion.Description == "" {
+				currentVersion.descriptionSeparator = descriptionSeparator
+			}
+			currentVersion.Description = appendParagraph(currentVersion.Description, strings.Join(trimmed, "\n"))
+		default:
+			history.Preamble = appendParagraph(history.Preamble, strings.Join(trimmed, "\n"))
+		}
+		return separator
+	}
+	// Versions whose header was seen already, which keep the separator of
+	// their first header.
+	versionsSeen := map[*Version]bool{}
+
+	isKeepAChangelog := false
+	lineNum := 0
 	for scanner.Scan() {
+		lineNum++
 		txt := scanner.Text()
 		logVerbose(txt)
-		logVerbose("isHeader", versionRegexp.MatchString(txt))
-		if matches, ok := matchLine(versionRegexp, txt); ok {
+		header := yankedRegexp.ReplaceAllString(txt, "")
+		logVerbose("isHeader", versionRegexp.MatchString(header))
+		if matches, ok := matchLine(versionRegexp, header); ok {
 			logVerbose("headerMatches:", matches, len(matches))
+			separator := flushPending()
 			currentHeader = matches[1]
 			currentSubHeader = ""
 			logVerbose("currentHeader:", currentHeader)
-			newVersion := history.GetVersionOrCreate(currentHeader)
-			newVersion.Date = versionDateFromMatches(matches)
+			// Keep the versions in the order they were written.
+			currentVersion = history.getVersionOrAppend(currentHeader)
+			if !versionsSeen[currentVersion] && separator != "\n\n" {
+				currentVersion.separator = separator
+			}
+			versionsSeen[currentVersion] = true
+			source.record(currentVersion, lineNum, currentHeader, "")
+			if source != nil && source.headers[currentVersion] == "" {
+				source.headers[currentVersion] = txt
+			}
+			// A repeated header without a date keeps the date of the first.
+			if date := versionDateFromMatches(matches); date != "" {
+				currentVersion.Date = date
+			}
+			style.detectHeaderStyle(header, currentHeader, currentVersion.Date)
+			currentVersion.Yanked = header != txt
+			currentSubsection = nil
+			currentLine = nil
+			if strings.HasPrefix(strings.TrimLeft(matches[0], "# "), "[") {
+				isKeepAChangelog = true
+			}
 			continue
 		}
 
 		logVerbose("isSubHeader", subheaderRegexp.MatchString(txt))
 		if matches, ok := matchLine(subheaderRegexp, txt); ok {
 			logVerbose("subHeaderMatches:", matches, len(matches))
+			separator := flushPending()
 			currentSubHeader = matches[1]
 			logVerbose("currentSubHeader:", currentSubHeader)
-			history.GetSubsectionOrCreate(currentHeader, currentSubHeader)
+			currentVersion = history.getVersionOrAppend(currentHeader)
+			currentSubsection = history.GetSubsection(currentHeader, currentSubHeader)
+			if currentSubsection == nil {
+				// Keep the subsections in the order they were written.
+				currentSubsection = NewSubsection(currentSubHeader)
+				currentVersion.Subsections = append(currentVersion.Subsections, currentSubsection)
+				if separator != "\n\n" {
+					currentSubsection.separator = separator
+				}
+			}
+			source.record(currentSubsection, lineNum, currentHeader, currentSubHeader)
+			currentLine = nil
 			continue
 		}
 
-		logVerbose("isChangeLine", changeLineRegexp.MatchString(txt))
-		if matches, ok := matchLine(changeLineRegexp, txt); ok {
+		logVerbose("isChangeLine", isChangeLine(txt))
+		if matches, ok := matchLine(changeLineRegexp, txt); ok && isChangeLine(txt) && !isNestedChangeLine(txt, currentLine, currentIndent) {
 			logVerbose("changeLineMatches:", matches, len(matches))
-			var line *ChangeLine
-			if more, ok := matchLine(changeLineRegexpWithRef, txt); ok {
-				// Has ref
-				line = &ChangeLine{
-					Summary:   more[1],
-					Reference: more[3],
-				}
-			} else {
-				// No ref
-				line = &ChangeLine{
-					Summary: matches[1],
-				}
+			separator := flushPending()
+			style.detectChangeLineStyle(txt)
+			line := newChangeLine(matches[1])
+			// The usual separator is a line break between changes, and a
+			// blank line before the first change of a list.
+			if first := currentLine == nil; first && separator != "\n\n" || !first && separator != "\n" {
+				line.separator = separator
 			}
 			logVerbose("newChangeLine:", line)
+			source.record(line, lineNum, currentHeader, currentSubHeader)
 			currentLine = line
+			currentIndent = lineIndent(txt)
 			if currentSubHeader == "" {
 				history.AddLineToVersion(currentHeader, line)
 			} else {
 				history.AddLineToSubsection(currentHeader, currentSubHeader, line)
 			}
 			continue
-		} else {
-			if strings.TrimSpace(txt) != "" && currentLine != nil {
-				currentLine.Summary += "\n\n" + txt
-			}
 		}
+
+		isStructured := currentVersion != nil || currentLine != nil
+		if isStructured && strings.HasPrefix(strings.TrimSpace(txt), "#") {
+			history.Diagnostics = append(history.Diagnostics, newDiagnostic(lineNum, txt, "unrecognized header"))
+		}
+		pending = append(pending, txt)
 	}
 	if err := scanner.Err(); err != nil {
-		log.Fatal("error reading history:", err)
+		return &ParseError{
+			Line:   lineNum + 1,
+			Reason: "error reading history: " + err.Error(),
+			Err:    err,
+		}
 	}
+
+	// Link reference definitions and comments following the last change
+	// are kept as the epilogue rather than folded into that change.
+	if currentVersion != nil || currentLine != nil {
+		for i, txt := range pending {
+			if isEpilogueStart(txt) && (i == 0 || strings.TrimSpace(pending[i-1]) == "") {
+				history.Epilogue = strings.Join(trimBlankLines(pending[i:]), "\n")
+				pending = pending[:i]
+				break
+			}
+		}
+	}
+	if separator := flushPending(); history.Epilogue != "" && separator != "\n\n" {
+		history.epilogueSeparator = separator
+	}
+	history.parseLinks()
+
+	// Version headers like "## [1.0.0]" are particular to Keep a Changelog.
+	if isKeepAChangelog {
+		history.Dialect = KeepAChangelog
+	} else {
+		history.Dialect = Jekyll
+	}
+	detected := style.withDefaults(history.Dialect.DefaultStyle())
+	if style.Bullet == "" {
+		detected.Indent = history.Dialect.DefaultStyle().Indent
+	}
+	history.Style = &detected
+
 	return nil
 }
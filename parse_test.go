@@ -1,9 +1,13 @@
 package changelog
 
 import (
+	"bufio"
+	"errors"
+	"io"
 	"os"
 	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -13,6 +17,13 @@ type testRegexpOutput struct {
 	matched []string
 }
 
+type testChangeLineOutput struct {
+	text      string
+	matched   []string
+	summary   string
+	reference string
+}
+
 var (
 	versions = []testRegexpOutput{
 		{
@@ -114,46 +125,108 @@ var (
 			matched: []string{"### Minor Enhancements", "Minor Enhancements"},
 		},
 	}
-	changelines = []testRegexpOutput{
+	changelines = []testChangeLineOutput{
 		{
-			text:    "* I made a really cool change!",
-			matched: []string{"* I made a really cool change!", "I made a really cool change!"},
+			text:      "* I made a really cool change!",
+			matched:   []string{"* I made a really cool change!", "I made a really cool change!"},
+			summary:   "I made a really cool change!",
+			reference: "",
 		},
 		{
-			text:    "  * I made a really cool change!",
-			matched: []string{"* I made a really cool change!", "I made a really cool change!"},
+			text:      "  * I made a really cool change!",
+			matched:   []string{"* I made a really cool change!", "I made a really cool change!"},
+			summary:   "I made a really cool change!",
+			reference: "",
 		},
 		{
-			text:    "  * The `coolest` change eVAR :smile: (#123)",
-			matched: []string{"* The `coolest` change eVAR :smile: (#123)", "The `coolest` change eVAR :smile:", " (#123)", "#123", "#123", ""},
+			text:      "  * The `coolest` change eVAR :smile: (#123)",
+			matched:   []string{"* The `coolest` change eVAR :smile: (#123)", "The `coolest` change eVAR :smile: (#123)"},
+			summary:   "The `coolest` change eVAR :smile:",
+			reference: "#123",
 		},
 		{
-			text:    "  * The `coolest` change eVAR :smile: (abcdef23)",
-			matched: []string{"* The `coolest` change eVAR :smile: (abcdef23)", "The `coolest` change eVAR :smile:", " (abcdef23)", "abcdef23", "", "abcdef23"},
+			text:      "  * The `coolest` change eVAR :smile: (abcdef23)",
+			matched:   []string{"* The `coolest` change eVAR :smile: (abcdef23)", "The `coolest` change eVAR :smile: (abcdef23)"},
+			summary:   "The `coolest` change eVAR :smile:",
+			reference: "abcdef23",
 		},
 		{
-			text:    "    * Fixed that narsty bug with tokenization (@carla)",
-			matched: []string{"* Fixed that narsty bug with tokenization (@carla)", "Fixed that narsty bug with tokenization", " (@carla)", "@carla", "", "@carla"},
+			text:      "    * Fixed that narsty bug with tokenization (@carla)",
+			matched:   []string{"* Fixed that narsty bug with tokenization (@carla)", "Fixed that narsty bug with tokenization (@carla)"},
+			summary:   "Fixed that narsty bug with tokenization",
+			reference: "@carla",
 		},
 		{
-			text:    "- I made a really cool change!",
-			matched: []string{"- I made a really cool change!", "I made a really cool change!"},
+			text:      "- I made a really cool change!",
+			matched:   []string{"- I made a really cool change!", "I made a really cool change!"},
+			summary:   "I made a really cool change!",
+			reference: "",
 		},
 		{
-			text:    "  - I made a really cool change!",
-			matched: []string{"- I made a really cool change!", "I made a really cool change!"},
+			text:      "  - I made a really cool change!",
+			matched:   []string{"- I made a really cool change!", "I made a really cool change!"},
+			summary:   "I made a really cool change!",
+			reference: "",
 		},
 		{
-			text:    "  - The `coolest` change eVAR :smile: (#123)",
-			matched: []string{"- The `coolest` change eVAR :smile: (#123)", "The `coolest` change eVAR :smile:", " (#123)", "#123", "#123", ""},
+			text:      "  - The `coolest` change eVAR :smile: (#123)",
+			matched:   []string{"- The `coolest` change eVAR :smile: (#123)", "The `coolest` change eVAR :smile: (#123)"},
+			summary:   "The `coolest` change eVAR :smile:",
+			reference: "#123",
 		},
 		{
-			text:    "  - The `coolest` change eVAR :smile: (abcdef23)",
-			matched: []string{"- The `coolest` change eVAR :smile: (abcdef23)", "The `coolest` change eVAR :smile:", " (abcdef23)", "abcdef23", "", "abcdef23"},
+			text:      "  - The `coolest` change eVAR :smile: (abcdef23)",
+			matched:   []string{"- The `coolest` change eVAR :smile: (abcdef23)", "The `coolest` change eVAR :smile: (abcdef23)"},
+			summary:   "The `coolest` change eVAR :smile:",
+			reference: "abcdef23",
 		},
 		{
-			text:    "    - Fixed that narsty bug with tokenization (@carla)",
-			matched: []string{"- Fixed that narsty bug with tokenization (@carla)", "Fixed that narsty bug with tokenization", " (@carla)", "@carla", "", "@carla"},
+			text:      "    - Fixed that narsty bug with tokenization (@carla)",
+			matched:   []string{"- Fixed that narsty bug with tokenization (@carla)", "Fixed that narsty bug with tokenization (@carla)"},
+			summary:   "Fixed that narsty bug with tokenization",
+			reference: "@carla",
+		},
+		{
+			text:      "  * Fix crash (#123, #456, @alice)",
+			matched:   []string{"* Fix crash (#123, #456, @alice)", "Fix crash (#123, #456, @alice)"},
+			summary:   "Fix crash",
+			reference: "#123, #456, @alice",
+		},
+		{
+			text:      "  * Fix crash (#123) (@alice)",
+			matched:   []string{"* Fix crash (#123) (@alice)", "Fix crash (#123) (@alice)"},
+			summary:   "Fix crash",
+			reference: "#123) (@alice",
+		},
+		{
+			text:      "  * Fix crash (parkr/changelog#12 0f4477a https://example.com/bug/12)",
+			matched:   []string{"* Fix crash (parkr/changelog#12 0f4477a https://example.com/bug/12)", "Fix crash (parkr/changelog#12 0f4477a https://example.com/bug/12)"},
+			summary:   "Fix crash",
+			reference: "parkr/changelog#12 0f4477a https://example.com/bug/12",
+		},
+		{
+			text:      "  * Fix crash on Windows (Vista) (#123)",
+			matched:   []string{"* Fix crash on Windows (Vista) (#123)", "Fix crash on Windows (Vista) (#123)"},
+			summary:   "Fix crash on Windows (Vista)",
+			reference: "#123",
+		},
+		{
+			text:      "  * Fix crash (see the docs)",
+			matched:   []string{"* Fix crash (see the docs)", "Fix crash (see the docs)"},
+			summary:   "Fix crash (see the docs)",
+			reference: "",
+		},
+		{
+			text:      "  * Fix crash (Windows) (parkr)",
+			matched:   []string{"* Fix crash (Windows) (parkr)", "Fix crash (Windows) (parkr)"},
+			summary:   "Fix crash (Windows)",
+			reference: "parkr",
+		},
+		{
+			text:      "  * (#123)",
+			matched:   []string{"* (#123)", "(#123)"},
+			summary:   "(#123)",
+			reference: "",
 		},
 	}
 	representativeChangelog = `## HEAD
@@ -211,18 +284,13 @@ func TestSubheaderRegexp(t *testing.T) {
 func TestChangelineRegexp(t *testing.T) {
 	for _, changeline := range changelines {
 		assert.Regexp(t, changeLineRegexp, changeline.text)
-		if len(changeline.matched) > 5 {
-			// Has ref
-			assert.Regexp(t, changeLineRegexpWithRef, changeline.text)
-			matches, ok := matchLine(changeLineRegexpWithRef, changeline.text)
-			assert.True(t, ok)
-			assert.Equal(t, matches, changeline.matched)
-		} else {
-			// No ref
-			matches, ok := matchLine(changeLineRegexp, changeline.text)
-			assert.True(t, ok)
-			assert.Equal(t, matches, changeline.matched)
-		}
+		matches, ok := matchLine(changeLineRegexp, changeline.text)
+		assert.True(t, ok)
+		assert.Equal(t, changeline.matched, matches)
+
+		summary, reference := splitReferences(matches[1])
+		assert.Equal(t, changeline.summary, summary, "summary of %q", changeline.text)
+		assert.Equal(t, changeline.reference, reference, "reference of %q", changeline.text)
 	}
 }
 
@@ -266,6 +334,8 @@ func TestParseChangelog_WithoutHeaders(t *testing.T) {
 	expected.AddLineToVersion("", &ChangeLine{Summary: "[ ] [Issue 1 complete](https://github.com/foo/bar/issue/1)"})
 	expected.AddLineToVersion("", &ChangeLine{Summary: "[ ] [Issue 2 complete, too!](https://github.com/foo/bar/issue/2)"})
 	expected.AddLineToVersion("", &ChangeLine{Summary: "[ ] [Secretly Issue 3, but masquerading as issue 4](https://github.com/foo/bar/issue/3)\n\n[You can see more later sometime maybe!](https://hi.there/foo)"})
+	expected.Preamble = "Oh hello there, it's [nice to see you](/foobar)."
+	expected.Style = &Style{Bullet: "-", Indent: 0, HeaderDepth: 2, DateSeparator: " / "}
 	changes := NewChangelog()
 
 	err = parseChangelog(fd, changes)
@@ -296,3 +366,113 @@ func TestParseChangelog_TrickyInput(t *testing.T) {
 		sortOrder:   -1,
 	})
 }
+
+func TestParseChangelog_Diagnostics(t *testing.T) {
+	input := `# Changelog
+
+## HEAD
+
+  * Fix the tokenizer (#1)
+## Notes:
+
+## 1.0.0 / 2015-02-20
+
+#### Internals!
+`
+	changes := NewChangelog()
+
+	err := parseChangelog(strings.NewReader(input), changes)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Fix the tokenizer\n## Notes:", changes.GetVersion("HEAD").History[0].Summary)
+	assert.Equal(t, "#### Internals!", changes.GetVersion("1.0.0").Description)
+	assert.Equal(t, []*ParseError{
+		{Line: 6, Column: 1, Text: "## Notes:", Reason: "unrecognized header"},
+		{Line: 10, Column: 1, Text: "#### Internals!", Reason: "unrecognized header"},
+	}, changes.Diagnostics)
+}
+
+func TestParseChangelog_Prose(t *testing.T) {
+	fd, err := os.Open("testdata/changelog-prose.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := NewChangelog()
+
+	err = parseChangelog(fd, changes)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "# Changelog\n\nAll notable changes to this project are documented in this file.\n\n<!-- Add new entries under HEAD. -->", changes.Preamble)
+	assert.Equal(t, "Work towards the next release.", changes.GetVersion("HEAD").Description)
+	assert.Equal(t, "Keep prose around the changelog\n    so nothing is lost on rewrite.", changes.GetSubsection("HEAD", "Minor Enhancements").History[1].Summary)
+	assert.Equal(t, "Maintain sort order\n\n  This paragraph belongs to the change above.", changes.GetVersion("1.1.0").History[0].Summary)
+	assert.Equal(t, "This release only contained documentation.", changes.GetSubsection("1.0.0", "Documentation").Description)
+	assert.Equal(t, "https://github.com/parkr/changelog/releases/tag/v1.1.0", changes.GetVersion("1.1.0").URL)
+	assert.Equal(t, "https://github.com/parkr/changelog/releases/tag/v1.0.0", changes.GetVersion("1.0.0").URL)
+	assert.Empty(t, changes.Epilogue)
+	assert.Empty(t, changes.Diagnostics)
+}
+
+func TestParseChangelog_ProseOnly(t *testing.T) {
+	changes := NewChangelog()
+
+	err := parseChangelog(strings.NewReader("# Changelog\n\nNothing here - yet.\n\n| Version | Date |\n"), changes)
+
+	assert.NoError(t, err)
+	assert.Empty(t, changes.Versions)
+	assert.Equal(t, "# Changelog\n\nNothing here - yet.\n\n| Version | Date |", changes.Preamble)
+	assert.Empty(t, changes.Epilogue)
+}
+
+func TestParseChangelog_IndentPerSection(t *testing.T) {
+	changes := NewChangelog()
+
+	err := parseChangelog(strings.NewReader("## 1.0.0\n\n* a\n\n## 0.9.0\n\n  * b\n  * c\n    * nested in c\n"), changes)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*ChangeLine{{Summary: "a"}}, changes.GetVersion("1.0.0").History)
+	assert.Equal(t, []*ChangeLine{
+		{Summary: "b"},
+		{Summary: "c\n    * nested in c"},
+	}, changes.GetVersion("0.9.0").History)
+}
+
+func TestParseChangelog_ReadError(t *testing.T) {
+	readErr := errors.New("connection reset")
+	input := io.MultiReader(strings.NewReader("## HEAD\n"), iotest.ErrReader(readErr))
+	changes := NewChangelog()
+
+	err := parseChangelog(input, changes)
+
+	var parseErr *ParseError
+	assert.True(t, errors.As(err, &parseErr), "expected a *ParseError, got %T", err)
+	assert.Equal(t, 2, parseErr.Line)
+	assert.True(t, errors.Is(err, readErr))
+	assert.Equal(t, "line 2: error reading history: connection reset", err.Error())
+}
+
+func TestParseChangelog_LineTooLong(t *testing.T) {
+	input := "## HEAD\n\n  * " + strings.Repeat("a", bufio.MaxScanTokenSize) + "\n"
+
+	changes, err := NewChangelogFromReader(strings.NewReader(input))
+
+	assert.Nil(t, changes)
+	var parseErr *ParseError
+	assert.True(t, errors.As(err, &parseErr), "expected a *ParseError, got %T", err)
+	assert.Equal(t, 3, parseErr.Line)
+	assert.True(t, errors.Is(err, bufio.ErrTooLong))
+}
+
+func TestParseErrorString(t *testing.T) {
+	assert.Equal(t, "line 3: unrecognized header", (&ParseError{Line: 3, Reason: "unrecognized header"}).Error())
+	assert.Equal(t, "line 3, column 5: unrecognized header", (&ParseError{Line: 3, Column: 5, Reason: "unrecognized header"}).Error())
+}
+
+func TestParseChangelog_DuplicateVersion(t *testing.T) {
+	changes := NewChangelog()
+	err := parseChangelog(strings.NewReader("## 1.0.0 / 2015-02-20\n\n  * Initial release\n\n## 1.0.0\n\n  * Tokenize a changelog\n"), changes)
+	assert.NoError(t, err)
+	assert.Len(t, changes.Versions, 1)
+	assert.Equal(t, "2015-02-20", changes.Versions[0].Date)
+	assert.Len(t, changes.Versions[0].History, 2)
+}
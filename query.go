@@ -0,0 +1,296 @@
+package changelog
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Between returns the versions which come after from, up to and including
+// to, in the order they are written, e.g. the changes a user gets by
+// upgrading from 3.8.2 to 4.1.0. The versions are compared like Compare
+// does, so neither from nor to need to be in the changelog, and to can be
+// "HEAD" or "[Unreleased]" to include the unreleased changes. The changes
+// without a version header are never included. Returns an error if from
+// or to is neither a semantic version nor the unreleased changes.
+func (c *Changelog) Between(from, to string) ([]*Version, error) {
+	for _, bound := range []string{from, to} {
+		if _, ok := parseSemver(bound); !ok && !isUnreleased(bound) {
+			return nil, fmt.Errorf("%q isn't a semantic version or HEAD", bound)
+		}
+	}
+	c.sortVersions()
+	fromVersion, toVersion := NewVersion(from), NewVersion(to)
+	versions := []*Version{}
+	for _, version := range c.Versions {
+		if version.Kind() == VersionKindUnversioned {
+			continue
+		}
+		if version.Compare(fromVersion) > 0 && version.Compare(toVersion) <= 0 {
+			versions = append(versions, version)
+		}
+	}
+	return versions, nil
+}
+
+// Since returns the versions which come after the given one, including
+// the unreleased changes, in the order they are written. Returns an error
+// if versionNum isn't a semantic version.
+func (c *Changelog) Since(versionNum string) ([]*Version, error) {
+	return c.Between(versionNum, "HEAD")
+}
+
+// CombineVersions combines the changes of the versions into a single
+// version with the given name, e.g. to list everything which changed
+// between two releases. The changes are grouped by subsection, in the
+// order they first appear, and changes listed in several versions are
+// only included once. The descriptions of the versions are left out.
+func CombineVersions(versionNum string, versions []*Version) *Version {
+	combined := NewVersion(versionNum)
+	seen := map[string]bool{}
+	add := func(lines *[]*ChangeLine, line *ChangeLine) {
+		key := strings.ToLower(changeLineKey(line))
+		if !seen[key] {
+			seen[key] = true
+			copied := *line
+			*lines = append(*lines, &copied)
+		}
+	}
+
+	for _, version := range versions {
+		for _, line := range version.History {
+			add(&combined.History, line)
+		}
+		for _, subsection := range version.Subsections {
+			var combinedSubsection *Subsection
+			for _, existing := range combined.Subsections {
+				if strings.EqualFold(strings.TrimSpace(existing.Name), strings.TrimSpace(subsection.Name)) {
+					combinedSubsection = existing
+					break
+				}
+			}
+			if combinedSubsection == nil {
+				combinedSubsection = NewSubsection(subsection.Name)
+				combined.Subsections = insertSubsection(combined.Subsections, combinedSubsection)
+			}
+			for _, line := range subsection.History {
+				add(&combinedSubsection.History, line)
+			}
+		}
+	}
+
+	subsections := []*Subsection{}
+	for _, subsection := range combined.Subsections {
+		if len(subsection.History) > 0 {
+			subsections = append(subsections, subsection)
+		}
+	}
+	combined.Subsections = subsections
+	return combined
+}
+
+// Filter selects change lines for Search. Each field which is set narrows
+// the search down further; the zero Filter matches every change.
+type Filter struct {
+	// Subsections are the names of the subsections to search, compared
+	// case-insensitively, e.g. "Security". The changes which aren't in a
+	// subsection have the name "".
+	Subsections []string
+	// ReferenceKinds selects the changes with a reference of one of these
+	// kinds, e.g. ReferenceCommit.
+	ReferenceKinds []ReferenceKind
+	// Authors selects the changes which mention one of these users, with
+	// or without the "@", either in their reference or in their summary.
+	Authors []string
+	// Keywords selects the changes whose summary contains every one of
+	// them, compared case-insensitively.
+	Keywords []string
+	// Pattern selects the changes whose summary matches it.
+	Pattern *regexp.Regexp
+	// Since and Until select the changes in the releases dated from Since
+	// up to and including Until. Either can be a year, a month or a day,
+	// e.g. "2024", "2024-06" or "2024-06-30". When a range is given,
+	// releases without a date are left out, and the unreleased changes are
+	// only included if Until is empty.
+	Since, Until string
+}
+
+// Match is a change line found by Search, with the version and the
+// subsection it's in. Subsection is nil for the changes which aren't in a
+// subsection.
+type Match struct {
+	Version    *Version
+	Subsection *Subsection
+	Line       *ChangeLine
+}
+
+// Matches are the changes found by Search, in the order they are written.
+type Matches []*Match
+
+var (
+	mentionRegexp     = regexp.MustCompile(`(?:^|[^\w@])@([\w-]+(?:/[\w-]+)?)`)
+	versionDateRegexp = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}`)
+)
+
+// Search returns the change lines which match the filter, in the order
+// they are written.
+func (c *Changelog) Search(filter *Filter) Matches {
+	c.sortVersions()
+	matches := Matches{}
+	for _, version := range c.Versions {
+		if !filter.matchesVersion(version) {
+			continue
+		}
+		for _, line := range version.History {
+			if filter.matchesLine(nil, line) {
+				matches = append(matches, &Match{Version: version, Line: line})
+			}
+		}
+		for _, subsection := range version.Subsections {
+			for _, line := range subsection.History {
+				if filter.matchesLine(subsection, line) {
+					matches = append(matches, &Match{Version: version, Subsection: subsection, Line: line})
+				}
+			}
+		}
+	}
+	return matches
+}
+
+// matchesVersion checks whether the version is in the date range.
+func (f *Filter) matchesVersion(version *Version) bool {
+	if f.Since == "" && f.Until == "" {
+		return true
+	}
+	if version.Kind() == VersionKindUnreleased {
+		return f.Until == ""
+	}
+	date := strings.TrimSpace(version.Date)
+	if !versionDateRegexp.MatchString(date) {
+		return false
+	}
+	// Dates compare as strings, and cutting the date down to the length
+	// of the bound compares it by year or month.
+	if f.Since != "" && truncate(date, len(f.Since)) < f.Since {
+		return false
+	}
+	if f.Until != "" && truncate(date, len(f.Until)) > f.Until {
+		return false
+	}
+	return true
+}
+
+// matchesLine checks whether the change in the subsection matches the
+// rest of the filter.
+func (f *Filter) matchesLine(subsection *Subsection, line *ChangeLine) bool {
+	if len(f.Subsections) > 0 {
+		name := ""
+		if subsection != nil {
+			name = subsection.Name
+		}
+		if !containsFold(f.Subsections, strings.TrimSpace(name)) {
+			return false
+		}
+	}
+	summary := strings.ToLower(line.Summary)
+	for _, keyword := range f.Keywords {
+		if !strings.Contains(summary, strings.ToLower(keyword)) {
+			return false
+		}
+	}
+	if f.Pattern != nil && !f.Pattern.MatchString(line.Summary) {
+		return false
+	}
+	if len(f.ReferenceKinds) == 0 && len(f.Authors) == 0 {
+		return true
+	}
+
+	refs := line.References()
+	if len(f.ReferenceKinds) > 0 {
+		found := false
+		for _, ref := range refs {
+			for _, kind := range f.ReferenceKinds {
+				found = found || ref.Kind == kind
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(f.Authors) > 0 {
+		authors := make([]string, len(f.Authors))
+		for i, author := range f.Authors {
+			authors[i] = strings.TrimPrefix(author, "@")
+		}
+		found := false
+		for _, ref := range refs {
+			found = found || ref.Kind == ReferenceUser && containsFold(authors, ref.ID)
+		}
+		for _, mention := range mentionRegexp.FindAllStringSubmatch(line.Summary, -1) {
+			found = found || containsFold(authors, mention[1])
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// truncate cuts the text down to at most n bytes.
+func truncate(text string, n int) string {
+	if len(text) > n {
+		return text[:n]
+	}
+	return text
+}
+
+// Versions returns copies of the versions of the matches with only the
+// matching changes in them, and without the subsections which have none,
+// e.g. to write the matches as a changelog.
+func (m Matches) Versions() []*Version {
+	versions := []*Version{}
+	var version *Version
+	var original *Version
+	subsections := map[*Subsection]*Subsection{}
+	for _, match := range m {
+		if match.Version != original {
+			original = match.Version
+			copied := *match.Version
+			copied.History = []*ChangeLine{}
+			copied.Subsections = []*Subsection{}
+			version = &copied
+			versions = append(versions, version)
+		}
+		line := *match.Line
+		if match.Subsection == nil {
+			version.History = append(version.History, &line)
+			continue
+		}
+		subsection, ok := subsections[match.Subsection]
+		if !ok {
+			copied := *match.Subsection
+			copied.History = []*ChangeLine{}
+			subsection = &copied
+			subsections[match.Subsection] = subsection
+			version.Subsections = append(version.Subsections, subsection)
+		}
+		subsection.History = append(subsection.History, &line)
+	}
+	return versions
+}
+
+// MarshalJSON encodes the match as the change, along with the version,
+// its date and the name of the subsection.
+func (m *Match) MarshalJSON() ([]byte, error) {
+	out := struct {
+		Version    string      `json:"version"`
+		Date       string      `json:"date,omitempty"`
+		Subsection string      `json:"subsection,omitempty"`
+		Change     *ChangeLine `json:"change"`
+	}{Version: m.Version.Version, Date: m.Version.Date, Change: m.Line}
+	if m.Subsection != nil {
+		out.Subsection = m.Subsection.Name
+	}
+	return json.Marshal(out)
+}
@@ -0,0 +1,202 @@
+package changelog
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// numbersOf returns a function which checks the error returned along with
+// the versions, and returns their numbers.
+func numbersOf(t *testing.T) func([]*Version, error) []string {
+	return func(versions []*Version, err error) []string {
+		assert.NoError(t, err)
+		return versionNumbers(&Changelog{Versions: versions})
+	}
+}
+
+func TestBetween(t *testing.T) {
+	history, err := NewChangelogFromFile("testdata/History.markdown")
+	assert.NoError(t, err)
+	numbers := numbersOf(t)
+
+	assert.Equal(t, []string{"2.5.3", "2.5.2", "2.5.1", "2.5.0"}, numbers(history.Between("2.4.0", "2.5.3")))
+	assert.Equal(t, []string{"2.1.1", "2.1.0"}, numbers(history.Between("v2.0.3", "[2.1.1]")))
+	assert.Equal(t, []string{"2.1.1", "2.1.0"}, numbers(history.Between("2.0.4", "2.1.5")))
+	assert.Equal(t, []string{"HEAD", "2.5.3"}, numbers(history.Between("2.5.2", "HEAD")))
+	assert.Empty(t, numbers(history.Between("2.5.3", "2.4.0")))
+	assert.Empty(t, numbers(history.Between("2.5.3", "2.5.3")))
+}
+
+func TestBetween_InvalidBounds(t *testing.T) {
+	history, err := NewChangelogFromFile("testdata/History.markdown")
+	assert.NoError(t, err)
+
+	_, err = history.Between("bogus", "2.5.3")
+	assert.EqualError(t, err, `"bogus" isn't a semantic version or HEAD`)
+	_, err = history.Between("2.4.0", "latest")
+	assert.EqualError(t, err, `"latest" isn't a semantic version or HEAD`)
+	_, err = history.Between("", "2.5.3")
+	assert.Error(t, err)
+	_, err = history.Since("2.x")
+	assert.Error(t, err)
+}
+
+func TestSince(t *testing.T) {
+	history := parseKeepAChangelog(t)
+	numbers := numbersOf(t)
+
+	assert.Equal(t, []string{"[Unreleased]", "1.1.1", "1.1.0"}, numbers(history.Since("0.0.5")))
+	assert.Equal(t, []string{"[Unreleased]"}, numbers(history.Since("1.1.1")))
+}
+
+func TestCombineVersions(t *testing.T) {
+	newer := NewVersion("2.0.0")
+	newer.Description = "A major release."
+	newer.History = []*ChangeLine{{Summary: "Drop Ruby 1.9", Reference: "#3"}}
+	newer.Subsections = []*Subsection{
+		{Name: Fixed, History: []*ChangeLine{{Summary: "Fix the parser", Reference: "#2"}}},
+		{Name: Added, History: []*ChangeLine{{Summary: "Add hooks", Reference: "#4"}}},
+		{Name: Removed, History: []*ChangeLine{}},
+	}
+	older := NewVersion("1.1.0")
+	older.Subsections = []*Subsection{
+		{Name: "fixed", History: []*ChangeLine{
+			{Summary: "Fix the tokenizer", Reference: "#1"},
+			{Summary: "fix the parser", Reference: "#2"},
+		}},
+		{Name: Security, History: []*ChangeLine{{Summary: "Escape the output"}}},
+	}
+
+	combined := CombineVersions("1.0.0...2.0.0", []*Version{newer, older})
+
+	assert.Equal(t, `## 1.0.0...2.0.0
+
+  * Drop Ruby 1.9 (#3)
+
+### Added
+
+  * Add hooks (#4)
+
+### Fixed
+
+  * Fix the parser (#2)
+  * Fix the tokenizer (#1)
+
+### Security
+
+  * Escape the output`, combined.String())
+	combined.Subsections[1].History[0].Summary = "Changed"
+	assert.Equal(t, "Fix the parser", newer.Subsections[0].History[0].Summary, "the versions are left alone")
+}
+
+const searchHistory = `## HEAD
+
+### Security
+
+  * Escape the titles of posts (#40)
+
+## 2.0.0 / 2024-03-01
+
+  * Drop Ruby 1.9, thanks to @alice (#30)
+
+### Security
+
+  * Sanitize the paths of includes (#29, @bob)
+
+### Bug Fixes
+
+  * Fix the tokenizer (commit: 0f4477a)
+
+## 1.1.0 / 2023-12-24
+
+### Security
+
+  * Escape the output of the jsonify filter (@alice)
+
+## 1.0.0
+
+  * Initial release
+`
+
+func searchSummaries(matches Matches) []string {
+	summaries := make([]string, len(matches))
+	for i, match := range matches {
+		summaries[i] = match.Version.Version + ": " + match.Line.Summary
+	}
+	return summaries
+}
+
+func TestSearch(t *testing.T) {
+	history := parseMarkdown(t, searchHistory)
+
+	assert.Len(t, history.Search(&Filter{}), 6)
+	assert.Equal(t, []string{
+		"HEAD: Escape the titles of posts",
+		"2.0.0: Sanitize the paths of includes",
+		"1.1.0: Escape the output of the jsonify filter",
+	}, searchSummaries(history.Search(&Filter{Subsections: []string{"security"}})))
+	assert.Equal(t, []string{"2.0.0: Drop Ruby 1.9, thanks to @alice", "1.0.0: Initial release"},
+		searchSummaries(history.Search(&Filter{Subsections: []string{""}})))
+	assert.Equal(t, []string{"2.0.0: Fix the tokenizer"},
+		searchSummaries(history.Search(&Filter{ReferenceKinds: []ReferenceKind{ReferenceCommit}})))
+	assert.Equal(t, []string{"2.0.0: Drop Ruby 1.9, thanks to @alice", "1.1.0: Escape the output of the jsonify filter"},
+		searchSummaries(history.Search(&Filter{Authors: []string{"@Alice"}})))
+	assert.Equal(t, []string{"2.0.0: Sanitize the paths of includes"},
+		searchSummaries(history.Search(&Filter{Authors: []string{"bob"}, ReferenceKinds: []ReferenceKind{ReferenceIssue}})))
+	assert.Equal(t, []string{"HEAD: Escape the titles of posts"},
+		searchSummaries(history.Search(&Filter{Keywords: []string{"escape", "Posts"}})))
+	assert.Equal(t, []string{"HEAD: Escape the titles of posts", "2.0.0: Sanitize the paths of includes"},
+		searchSummaries(history.Search(&Filter{Pattern: regexp.MustCompile(`(?i)^(escape|sanitize) the (titles|paths)`)})))
+}
+
+func TestSearch_Dates(t *testing.T) {
+	history := parseMarkdown(t, searchHistory)
+	security := []string{Security}
+
+	assert.Equal(t, []string{"HEAD: Escape the titles of posts", "2.0.0: Sanitize the paths of includes"},
+		searchSummaries(history.Search(&Filter{Subsections: security, Since: "2024"})))
+	assert.Equal(t, []string{"2.0.0: Sanitize the paths of includes", "1.1.0: Escape the output of the jsonify filter"},
+		searchSummaries(history.Search(&Filter{Subsections: security, Since: "2023-12", Until: "2024-03-01"})))
+	assert.Equal(t, []string{"1.1.0: Escape the output of the jsonify filter"},
+		searchSummaries(history.Search(&Filter{Subsections: security, Until: "2023"})))
+	assert.Empty(t, history.Search(&Filter{Since: "2024-03-02", Until: "2024-12"}))
+}
+
+func TestMatchesVersions(t *testing.T) {
+	history := parseMarkdown(t, searchHistory)
+	matches := history.Search(&Filter{Pattern: regexp.MustCompile(`^(Drop|Fix|Escape the output)`)})
+
+	versions := matches.Versions()
+
+	assert.Len(t, versions, 2)
+	assert.Equal(t, `## 2.0.0 / 2024-03-01
+
+  * Drop Ruby 1.9, thanks to @alice (#30)
+
+### Bug Fixes
+
+  * Fix the tokenizer (commit: 0f4477a)`, versions[0].String())
+	assert.Equal(t, "## 1.1.0 / 2023-12-24\n\n### Security\n\n  * Escape the output of the jsonify filter (@alice)", versions[1].String())
+	assert.Len(t, history.GetVersion("2.0.0").Subsections, 2, "the versions are left alone")
+}
+
+func TestMatchJSON(t *testing.T) {
+	history := parseMarkdown(t, searchHistory)
+
+	data, err := json.Marshal(history.Search(&Filter{Authors: []string{"bob"}}))
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{
+		"version": "2.0.0",
+		"date": "2024-03-01",
+		"subsection": "Security",
+		"change": {
+			"summary": "Sanitize the paths of includes",
+			"reference": "#29, @bob",
+			"references": [{"kind": "issue", "text": "#29", "id": "29"}, {"kind": "user", "text": "@bob", "id": "bob"}]
+		}
+	}]`, string(data))
+}
@@ -0,0 +1,239 @@
+package changelog
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ReferenceKind is the kind of thing a Reference points to.
+type ReferenceKind int
+
+const (
+	// ReferenceOther is a reference which isn't any of the other kinds,
+	// like a bare username.
+	ReferenceOther ReferenceKind = iota
+	// ReferenceIssue is an issue, or a pull request on forges which number
+	// them together, e.g. "#123".
+	ReferenceIssue
+	// ReferencePullRequest is a pull or merge request, e.g. "!12".
+	ReferencePullRequest
+	// ReferenceUser is a user or team, e.g. "@parkr".
+	ReferenceUser
+	// ReferenceCommit is a commit SHA, e.g. "0f4477a" or "commit: 0f4477".
+	// A bare SHA has at least one letter, so that "2015020" isn't one.
+	ReferenceCommit
+	// ReferenceURL is a link, e.g. "https://example.com/bug/12".
+	ReferenceURL
+	// ReferenceCrossRepo is an issue in another repository, e.g.
+	// "parkr/changelog#12".
+	ReferenceCrossRepo
+)
+
+// String returns the name of the kind, e.g. "issue".
+func (k ReferenceKind) String() string {
+	switch k {
+	case ReferenceIssue:
+		return "issue"
+	case ReferencePullRequest:
+		return "pull-request"
+	case ReferenceUser:
+		return "user"
+	case ReferenceCommit:
+		return "commit"
+	case ReferenceURL:
+		return "url"
+	case ReferenceCrossRepo:
+		return "cross-repo"
+	}
+	return "other"
+}
+
+// ParseReferenceKind returns the kind with the given name, as returned by
+// String, e.g. ReferenceIssue for "issue".
+func ParseReferenceKind(name string) (ReferenceKind, error) {
+	for kind := ReferenceOther; kind <= ReferenceCrossRepo; kind++ {
+		if strings.EqualFold(name, kind.String()) {
+			return kind, nil
+		}
+	}
+	return ReferenceOther, fmt.Errorf("unknown reference kind %q", name)
+}
+
+// Reference is one of the references of a ChangeLine.
+type Reference struct {
+	Kind ReferenceKind
+	// Text is the reference as written, e.g. "parkr/changelog#12". A
+	// reference can be linked in markdown, e.g. "[3ab386f](https://...)",
+	// in which case its kind and ID are those of the link text.
+	Text string
+	// ID identifies what the reference points to: the number of an issue
+	// or pull request, the name of a user, the SHA of a commit or the URL
+	// of a link.
+	ID string
+	// Repo is the repository of a ReferenceCrossRepo, e.g.
+	// "parkr/changelog".
+	Repo string
+}
+
+var (
+	referenceTokenRegexp = regexp.MustCompile(`\[[^\]\s]+\]\([^\s()]+\)|(?i:commit:?\s+)?[^\s,()]+`)
+	markdownLinkRegexp   = regexp.MustCompile(`^\[([^\]\s]+)\]\(([^\s()]+)\)$`)
+	issueRefRegexp       = regexp.MustCompile(`^#(\d+)$`)
+	pullRequestRefRegexp = regexp.MustCompile(`^!(\d+)$`)
+	crossRepoRefRegexp   = regexp.MustCompile(`^([\w.-]+/[\w.-]+)#(\d+)$`)
+	userRefRegexp        = regexp.MustCompile(`^@([\w-]+(?:/[\w-]+)?)$`)
+	commitRefRegexp      = regexp.MustCompile(`^(?:(?i:commit:?\s+)([0-9a-f]{4,40})|([0-9a-f]{7,40}))$`)
+	urlRefRegexp         = regexp.MustCompile(`^https?://\S+$`)
+	bareReferenceRegexp  = regexp.MustCompile(`^@?[[:word:]]+$`)
+)
+
+// markdownLink splits a reference linked in markdown, like
+// "[3ab386f](https://...)", into its text and URL.
+func markdownLink(text string) (label, url string, ok bool) {
+	matches := markdownLinkRegexp.FindStringSubmatch(text)
+	if matches == nil {
+		return "", "", false
+	}
+	return matches[1], matches[2], true
+}
+
+// parseReference works out the kind of a single reference.
+func parseReference(text string) Reference {
+	if label, url, ok := markdownLink(text); ok {
+		ref := parseReference(label)
+		ref.Text = text
+		if ref.Kind == ReferenceOther {
+			ref.Kind, ref.ID = ReferenceURL, url
+		}
+		return ref
+	}
+	if matches := issueRefRegexp.FindStringSubmatch(text); matches != nil {
+		return Reference{Kind: ReferenceIssue, Text: text, ID: matches[1]}
+	}
+	if matches := pullRequestRefRegexp.FindStringSubmatch(text); matches != nil {
+		return Reference{Kind: ReferencePullRequest, Text: text, ID: matches[1]}
+	}
+	if matches := crossRepoRefRegexp.FindStringSubmatch(text); matches != nil {
+		return Reference{Kind: ReferenceCrossRepo, Text: text, ID: matches[2], Repo: matches[1]}
+	}
+	if matches := userRefRegexp.FindStringSubmatch(text); matches != nil {
+		return Reference{Kind: ReferenceUser, Text: text, ID: matches[1]}
+	}
+	if matches := commitRefRegexp.FindStringSubmatch(text); matches != nil && (matches[1] != "" || strings.ContainsAny(matches[2], "abcdef")) {
+		return Reference{Kind: ReferenceCommit, Text: text, ID: matches[1] + matches[2]}
+	}
+	if urlRefRegexp.MatchString(text) {
+		return Reference{Kind: ReferenceURL, Text: text, ID: text}
+	}
+	return Reference{Kind: ReferenceOther, Text: text, ID: text}
+}
+
+// splitReferenceList splits a list of references, like "#123, @alice" or
+// "#123) (@alice", into the individual references. "commit: 0f4477" and
+// "[3ab386f](https://...)" are single references.
+func splitReferenceList(list string) []string {
+	return referenceTokenRegexp.FindAllString(list, -1)
+}
+
+// referenceGroupSeparator separates the groups of references listed as
+// "(#123) (@alice)", once their outer parentheses are removed.
+const referenceGroupSeparator = ") ("
+
+// newChangeLine creates the change for the text of a list item, splitting
+// the references at its end from the summary.
+func newChangeLine(text string) *ChangeLine {
+	summary, reference := splitReferences(text)
+	line := &ChangeLine{
+		Summary:   summary,
+		Reference: strings.Join(strings.Split(reference, referenceGroupSeparator), ", "),
+	}
+	if line.Reference != reference {
+		line.referenceSource = reference
+	}
+	return line
+}
+
+// writtenReference returns the references the way they were written, or
+// Reference if it was changed since.
+func (l *ChangeLine) writtenReference() string {
+	if l.referenceSource != "" && strings.Join(strings.Split(l.referenceSource, referenceGroupSeparator), ", ") == l.Reference {
+		return l.referenceSource
+	}
+	return l.Reference
+}
+
+// References parses the Reference of the change into its individual
+// references, e.g. "#123, @alice" into an issue and a mention.
+func (l *ChangeLine) References() []Reference {
+	texts := splitReferenceList(l.Reference)
+	if len(texts) == 0 {
+		return nil
+	}
+	refs := make([]Reference, len(texts))
+	for i, text := range texts {
+		refs[i] = parseReference(text)
+	}
+	return refs
+}
+
+// trailingGroup finds the parenthesized group at the end of the text,
+// like " (#123)" or " ([3ab386f](https://...))", and returns the index of
+// the space before it.
+func trailingGroup(text string) (int, bool) {
+	if !strings.HasSuffix(text, ")") {
+		return 0, false
+	}
+	depth := 0
+	for open := len(text) - 1; open >= 0; open-- {
+		switch text[open] {
+		case ')':
+			depth++
+		case '(':
+			depth--
+		}
+		if depth == 0 {
+			if open < 1 || text[open-1] != ' ' {
+				return 0, false
+			}
+			return open - 1, true
+		}
+	}
+	return 0, false
+}
+
+// isReferenceList checks whether the text is made of references of a
+// known kind only, e.g. "#123, @alice".
+func isReferenceList(text string) bool {
+	texts := splitReferenceList(text)
+	for _, text := range texts {
+		if parseReference(text).Kind == ReferenceOther {
+			return false
+		}
+	}
+	return len(texts) > 0
+}
+
+// splitReferences splits the text of a change line into the summary and
+// the references at its end, without their outer parentheses. The
+// references can be listed in one group, like "(#123, #456, @alice)", or
+// in several, like "(#123) (@alice)", which gives "#123) (@alice". A single
+// group with one bare word, like "(parkr)", is a reference too.
+func splitReferences(text string) (summary, reference string) {
+	summary = text
+	for {
+		start, ok := trailingGroup(summary)
+		if !ok || strings.TrimSpace(summary[:start]) == "" || !isReferenceList(summary[start+2:len(summary)-1]) {
+			break
+		}
+		summary = summary[:start]
+	}
+	if summary == text {
+		start, ok := trailingGroup(text)
+		if !ok || strings.TrimSpace(text[:start]) == "" || !bareReferenceRegexp.MatchString(text[start+2:len(text)-1]) {
+			return text, ""
+		}
+		summary = text[:start]
+	}
+	return summary, text[len(summary)+2 : len(text)-1]
+}
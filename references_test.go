@@ -0,0 +1,118 @@
+package changelog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangeLineReferences(t *testing.T) {
+	line := &ChangeLine{Summary: "Fix crash", Reference: "#123, !45, parkr/changelog#12, @alice 0f4477a commit: 0f4477 https://example.com/bug/12 carla"}
+	assert.Equal(t, []Reference{
+		{Kind: ReferenceIssue, Text: "#123", ID: "123"},
+		{Kind: ReferencePullRequest, Text: "!45", ID: "45"},
+		{Kind: ReferenceCrossRepo, Text: "parkr/changelog#12", ID: "12", Repo: "parkr/changelog"},
+		{Kind: ReferenceUser, Text: "@alice", ID: "alice"},
+		{Kind: ReferenceCommit, Text: "0f4477a", ID: "0f4477a"},
+		{Kind: ReferenceCommit, Text: "commit: 0f4477", ID: "0f4477"},
+		{Kind: ReferenceURL, Text: "https://example.com/bug/12", ID: "https://example.com/bug/12"},
+		{Kind: ReferenceOther, Text: "carla", ID: "carla"},
+	}, line.References())
+
+	assert.Nil(t, (&ChangeLine{Summary: "Fix crash"}).References())
+}
+
+func TestChangeLineReferences_Commits(t *testing.T) {
+	line := &ChangeLine{Summary: "Fix crash", Reference: "2015020, 12345678, commit: 1234567, 3ab386f, [3ab386f](https://github.com/jekyll/jekyll/commit/3ab386f1b096be25a24fe038fc70fd0fb08d545d)"}
+	assert.Equal(t, []Reference{
+		{Kind: ReferenceOther, Text: "2015020", ID: "2015020"},
+		{Kind: ReferenceOther, Text: "12345678", ID: "12345678"},
+		{Kind: ReferenceCommit, Text: "commit: 1234567", ID: "1234567"},
+		{Kind: ReferenceCommit, Text: "3ab386f", ID: "3ab386f"},
+		{Kind: ReferenceCommit, Text: "[3ab386f](https://github.com/jekyll/jekyll/commit/3ab386f1b096be25a24fe038fc70fd0fb08d545d)", ID: "3ab386f"},
+	}, line.References())
+
+	linked := &ChangeLine{Summary: "Fix crash", Reference: "[#12](https://example.com/12), [docs](https://example.com/docs)"}
+	assert.Equal(t, []Reference{
+		{Kind: ReferenceIssue, Text: "[#12](https://example.com/12)", ID: "12"},
+		{Kind: ReferenceURL, Text: "[docs](https://example.com/docs)", ID: "https://example.com/docs"},
+	}, linked.References())
+}
+
+func TestParseChangelog_MarkdownLinkedCommit(t *testing.T) {
+	history, err := NewChangelogFromFile("testdata/History.markdown")
+	assert.NoError(t, err)
+
+	var line *ChangeLine
+	for _, l := range history.GetSubsection("HEAD", "Development Fixes").History {
+		if strings.HasPrefix(l.Summary, "Update JRuby testing") {
+			line = l
+		}
+	}
+	assert.Equal(t, "Update JRuby testing to 9K", line.Summary)
+	assert.Equal(t, "[3ab386f](https://github.com/jekyll/jekyll/commit/3ab386f1b096be25a24fe038fc70fd0fb08d545d)", line.Reference)
+	assert.Equal(t, ReferenceCommit, line.References()[0].Kind)
+	assert.Equal(t, "3ab386f", line.References()[0].ID)
+
+	resolver, err := NewResolver("https://github.com/jekyll/jekyll")
+	assert.NoError(t, err)
+	version := &Version{Version: "HEAD", History: []*ChangeLine{line}}
+	assert.Equal(t, line.Reference, version.Linkify(resolver, true).History[0].Reference)
+	assert.Equal(t, "https://github.com/jekyll/jekyll/commit/3ab386f1b096be25a24fe038fc70fd0fb08d545d", version.Linkify(resolver, false).History[0].Reference)
+}
+
+func TestReferenceKindString(t *testing.T) {
+	assert.Equal(t, "other", ReferenceOther.String())
+	assert.Equal(t, "issue", ReferenceIssue.String())
+	assert.Equal(t, "pull-request", ReferencePullRequest.String())
+	assert.Equal(t, "user", ReferenceUser.String())
+	assert.Equal(t, "commit", ReferenceCommit.String())
+	assert.Equal(t, "url", ReferenceURL.String())
+	assert.Equal(t, "cross-repo", ReferenceCrossRepo.String())
+}
+
+func TestParseReferenceKind(t *testing.T) {
+	for kind := ReferenceOther; kind <= ReferenceCrossRepo; kind++ {
+		parsed, err := ParseReferenceKind(kind.String())
+		assert.NoError(t, err)
+		assert.Equal(t, kind, parsed)
+	}
+	parsed, err := ParseReferenceKind("Pull-Request")
+	assert.NoError(t, err)
+	assert.Equal(t, ReferencePullRequest, parsed)
+	_, err = ParseReferenceKind("ticket")
+	assert.EqualError(t, err, `unknown reference kind "ticket"`)
+}
+
+func TestChangelog_WritesWhatItParses_References(t *testing.T) {
+	source := `## HEAD
+
+  * Fix crash (#123, #456, @alice)
+  * Fix crash (#123) (@alice)
+  * Fix crash on Windows (Vista) (parkr/changelog#12)
+  * Fix crash (see the docs)
+  * Add basic support for JRuby (commit: 0f4477)
+`
+	history, err := NewChangelogFromReader(strings.NewReader(source))
+	assert.NoError(t, err)
+	assert.Equal(t, source, history.String())
+
+	lines := history.GetVersion("HEAD").History
+	assert.Len(t, lines[0].References(), 3)
+	assert.Equal(t, "Fix crash", lines[1].Summary)
+	assert.Equal(t, "#123, @alice", lines[1].Reference)
+	assert.True(t, history.HasLine("HEAD", "", &ChangeLine{Summary: "Fix crash", Reference: "#123, @alice"}))
+	assert.Equal(t, []Reference{
+		{Kind: ReferenceIssue, Text: "#123", ID: "123"},
+		{Kind: ReferenceUser, Text: "@alice", ID: "alice"},
+	}, lines[1].References())
+	assert.Equal(t, "Fix crash on Windows (Vista)", lines[2].Summary)
+	assert.Empty(t, lines[3].Reference)
+	assert.Equal(t, "Add basic support for JRuby", lines[4].Summary)
+	assert.Equal(t, []Reference{{Kind: ReferenceCommit, Text: "commit: 0f4477", ID: "0f4477"}}, lines[4].References())
+
+	// Once the references are changed, they are written as a single list.
+	lines[1].Reference = "#123, @alice, @bob"
+	assert.Equal(t, "  * Fix crash (#123, @alice, @bob)", lines[1].String())
+}
@@ -0,0 +1,84 @@
+package changelog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// GetUnreleased fetches the Version which collects the changes that haven't
+// been released yet, e.g. "HEAD" or "[Unreleased]". Returns nil if the
+// changelog doesn't have one.
+func (c *Changelog) GetUnreleased() *Version {
+	for _, v := range c.Versions {
+		if isUnreleased(v.Version) {
+			return v
+		}
+	}
+	return nil
+}
+
+// Release turns the unreleased changes into the given version, released on
+// the given date, e.g. "2006-01-02", or undated if the date is empty. A
+// version which only differs from an existing one by a leading "v" counts
+// as existing. The version keeps its direct history
+// and subsections, and is placed above the previous releases. If the
+// Dialect keeps a section for unreleased changes, a new, empty one is
+// created. Links to the versions are updated when the changelog is
+// written.
+func (c *Changelog) Release(versionNum, date string) error {
+	if strings.TrimSpace(versionNum) == "" || isUnreleased(versionNum) {
+		return fmt.Errorf("%q is not a version which can be released", versionNum)
+	}
+	unreleased := c.GetUnreleased()
+	if unreleased == nil {
+		return fmt.Errorf("there are no unreleased changes to release as %s", versionNum)
+	}
+	if date != "" {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return fmt.Errorf("%q isn't a date formatted like 2006-01-02", date)
+		}
+	}
+	for _, v := range c.Versions {
+		if trimVersionPrefix(v.Version) == trimVersionPrefix(versionNum) {
+			return fmt.Errorf("version %s already exists", v.Version)
+		}
+	}
+
+	for i, v := range c.Versions {
+		if v == unreleased {
+			c.Versions = append(c.Versions[:i], c.Versions[i+1:]...)
+			break
+		}
+	}
+	urlLabel := unreleased.urlLabel
+	unreleased.Version = versionNum
+	unreleased.Date = date
+	unreleased.sortOrder = 1
+	unreleased.urlLabel = ""
+	if _, ok := parseSemver(versionNum); ok {
+		c.insertVersion(unreleased)
+	} else {
+		// Without a semantic version to go by, the newest release goes on
+		// top.
+		for _, v := range c.Versions {
+			if v.sortOrder > 0 {
+				v.sortOrder++
+			}
+		}
+		c.Versions = append(c.Versions, unreleased)
+		c.sortVersions()
+	}
+
+	if c.dialect().KeepsUnreleased() {
+		next := NewVersion(c.dialect().Unreleased())
+		next.urlLabel = urlLabel
+		c.appendVersion(next)
+	}
+	return nil
+}
+
+// trimVersionPrefix removes a leading "v" or "V" from a version number.
+func trimVersionPrefix(versionNum string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(versionNum), "v"), "V")
+}
@@ -0,0 +1,98 @@
+package changelog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetUnreleased(t *testing.T) {
+	history := NewChangelog()
+	assert.Nil(t, history.GetUnreleased())
+
+	history.GetVersionOrCreate("1.0.0")
+	assert.Nil(t, history.GetUnreleased())
+
+	unreleased := history.GetVersionOrCreate("[Unreleased]")
+	assert.Equal(t, unreleased, history.GetUnreleased())
+}
+
+func TestRelease_Jekyll(t *testing.T) {
+	history, err := NewChangelogFromReader(strings.NewReader(`## HEAD
+
+### Bug Fixes
+
+  * Fix the tokenizer (#123)
+
+## 4.1.0 / 2026-01-02
+
+  * Add a release command (#100)
+`))
+	assert.NoError(t, err)
+
+	assert.NoError(t, history.Release("4.2.0", "2026-10-17"))
+
+	assert.Nil(t, history.GetUnreleased())
+	assertSortOrder(t, history, "4.2.0", 1)
+	assertSortOrder(t, history, "4.1.0", 2)
+	assert.Equal(t, `## 4.2.0 / 2026-10-17
+
+### Bug Fixes
+
+  * Fix the tokenizer (#123)
+
+## 4.1.0 / 2026-01-02
+
+  * Add a release command (#100)
+`, history.String())
+}
+
+func TestRelease_KeepAChangelog(t *testing.T) {
+	history := parseKeepAChangelog(t)
+	unreleased := history.GetUnreleased()
+
+	assert.NoError(t, history.Release("1.2.0", "2026-10-17"))
+
+	release := history.GetVersion("1.2.0")
+	assert.True(t, unreleased == release, "the unreleased version should have been renamed")
+	assert.Len(t, release.Subsections, 3)
+	assert.Equal(t, "[Unreleased]", history.Versions[0].Version)
+	assert.Empty(t, history.Versions[0].Subsections)
+	assert.Equal(t, release, history.Versions[1])
+
+	actual := history.String()
+	assert.Contains(t, actual, "\n## [Unreleased]\n\n## [1.2.0] - 2026-10-17\n\n### Added\n")
+	assert.Equal(t, `[unreleased]: https://github.com/olivierlacan/keep-a-changelog/compare/v1.2.0...HEAD
+[1.2.0]: https://github.com/olivierlacan/keep-a-changelog/compare/v1.1.1...v1.2.0
+[1.1.1]: https://github.com/olivierlacan/keep-a-changelog/compare/v1.1.0...v1.1.1
+[1.1.0]: https://github.com/olivierlacan/keep-a-changelog/compare/v0.0.5...v1.1.0
+[0.0.5]: https://github.com/olivierlacan/keep-a-changelog/compare/v0.0.1...v0.0.5
+[0.0.1]: https://github.com/olivierlacan/keep-a-changelog/releases/tag/v0.0.1`, lastLines(actual, 6))
+}
+
+func TestRelease_NotSemver(t *testing.T) {
+	history := NewChangelog()
+	history.AddLineToVersion("HEAD", &ChangeLine{Summary: "Fix the tokenizer"})
+	history.GetVersionOrCreate("1.0.0")
+
+	assert.NoError(t, history.Release("2026.10", ""))
+
+	assert.Equal(t, "2026.10", history.Versions[0].Version)
+	assert.Equal(t, "1.0.0", history.Versions[1].Version)
+}
+
+func TestRelease_Errors(t *testing.T) {
+	history := NewChangelog()
+	history.GetVersionOrCreate("1.0.0")
+	assert.EqualError(t, history.Release("1.1.0", "2026-10-17"), "there are no unreleased changes to release as 1.1.0")
+
+	history.GetVersionOrCreate("HEAD")
+	assert.EqualError(t, history.Release("1.0.0", "2026-10-17"), "version 1.0.0 already exists")
+	assert.EqualError(t, history.Release("v1.0.0", "2026-10-17"), "version 1.0.0 already exists")
+	assert.EqualError(t, history.Release("1.1.0", "17/10/2026"), `"17/10/2026" isn't a date formatted like 2006-01-02`)
+	assert.EqualError(t, history.Release("1.1.0", "2026-13-01"), `"2026-13-01" isn't a date formatted like 2006-01-02`)
+	assert.EqualError(t, history.Release("HEAD", "2026-10-17"), `"HEAD" is not a version which can be released`)
+	assert.EqualError(t, history.Release("", "2026-10-17"), `"" is not a version which can be released`)
+	assert.NotNil(t, history.GetUnreleased())
+}
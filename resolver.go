@@ -0,0 +1,200 @@
+package changelog
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// A Resolver turns references into URLs.
+type Resolver interface {
+	// URL returns the URL the reference points to, or an empty string if
+	// it can't be resolved.
+	URL(ref Reference) string
+}
+
+// Forge describes how a code forge, like GitHub, lays out the URLs of a
+// repository. The templates can use "{base}" for the URL of the forge,
+// e.g. "https://github.com", "{repo}" for the repository, e.g.
+// "parkr/changelog", and "{id}" for the ID of the reference. An empty
+// template means the forge has no URL for that kind of reference.
+type Forge struct {
+	// Name identifies the forge, e.g. "github".
+	Name string
+	// Host is the host of the public instance of the forge, if any, e.g.
+	// "github.com".
+	Host           string
+	IssueURL       string
+	PullRequestURL string
+	UserURL        string
+	CommitURL      string
+}
+
+var (
+	// GitHub is the Forge for https://github.com and GitHub Enterprise.
+	GitHub = &Forge{
+		Name:           "github",
+		Host:           "github.com",
+		IssueURL:       "{base}/{repo}/issues/{id}",
+		PullRequestURL: "{base}/{repo}/pull/{id}",
+		UserURL:        "{base}/{id}",
+		CommitURL:      "{base}/{repo}/commit/{id}",
+	}
+	// GitLab is the Forge for https://gitlab.com and self-managed GitLab.
+	GitLab = &Forge{
+		Name:           "gitlab",
+		Host:           "gitlab.com",
+		IssueURL:       "{base}/{repo}/-/issues/{id}",
+		PullRequestURL: "{base}/{repo}/-/merge_requests/{id}",
+		UserURL:        "{base}/{id}",
+		CommitURL:      "{base}/{repo}/-/commit/{id}",
+	}
+	// Gitea is the Forge for Gitea and Forgejo, e.g. https://codeberg.org.
+	Gitea = &Forge{
+		Name:           "gitea",
+		Host:           "codeberg.org",
+		IssueURL:       "{base}/{repo}/issues/{id}",
+		PullRequestURL: "{base}/{repo}/pulls/{id}",
+		UserURL:        "{base}/{id}",
+		CommitURL:      "{base}/{repo}/commit/{id}",
+	}
+	// Bitbucket is the Forge for https://bitbucket.org.
+	Bitbucket = &Forge{
+		Name:           "bitbucket",
+		Host:           "bitbucket.org",
+		IssueURL:       "{base}/{repo}/issues/{id}",
+		PullRequestURL: "{base}/{repo}/pull-requests/{id}",
+		CommitURL:      "{base}/{repo}/commits/{id}",
+	}
+
+	// Forges lists the built-in forges.
+	Forges = []*Forge{GitHub, GitLab, Gitea, Bitbucket}
+)
+
+// ForgeByName fetches the built-in Forge with the given name, e.g.
+// "gitlab". The comparison is case-insensitive.
+func ForgeByName(name string) (*Forge, error) {
+	for _, forge := range Forges {
+		if strings.EqualFold(forge.Name, name) {
+			return forge, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown forge %q", name)
+}
+
+// Resolver returns a Resolver for the repository at repoURL, e.g.
+// "https://github.com/parkr/changelog".
+func (f *Forge) Resolver(repoURL string) (Resolver, error) {
+	parsed, err := url.Parse(strings.TrimSuffix(strings.TrimSuffix(repoURL, "/"), ".git"))
+	if err != nil {
+		return nil, err
+	}
+	repo := strings.Trim(parsed.Path, "/")
+	if parsed.Scheme == "" || parsed.Host == "" || repo == "" {
+		return nil, fmt.Errorf("%q is not the URL of a repository, like https://%s/owner/repo", repoURL, f.Host)
+	}
+	return &forgeResolver{
+		forge: f,
+		base:  parsed.Scheme + "://" + parsed.Host,
+		repo:  repo,
+	}, nil
+}
+
+// NewResolver returns a Resolver for the repository at repoURL, e.g.
+// "https://github.com/parkr/changelog". The forge is detected from the
+// host; other hosts are taken to be GitHub Enterprise instances.
+func NewResolver(repoURL string) (Resolver, error) {
+	forge := GitHub
+	if parsed, err := url.Parse(repoURL); err == nil {
+		for _, f := range Forges {
+			if strings.EqualFold(parsed.Hostname(), f.Host) {
+				forge = f
+			}
+		}
+	}
+	return forge.Resolver(repoURL)
+}
+
+// forgeResolver resolves references to a repository on a forge.
+type forgeResolver struct {
+	forge *Forge
+	// base is the URL of the forge, e.g. "https://github.com".
+	base string
+	// repo is the repository, e.g. "parkr/changelog".
+	repo string
+}
+
+func (r *forgeResolver) URL(ref Reference) string {
+	template, repo := "", r.repo
+	switch ref.Kind {
+	case ReferenceIssue:
+		template = r.forge.IssueURL
+	case ReferencePullRequest:
+		template = r.forge.PullRequestURL
+	case ReferenceCrossRepo:
+		template, repo = r.forge.IssueURL, ref.Repo
+	case ReferenceUser:
+		template = r.forge.UserURL
+	case ReferenceCommit:
+		template = r.forge.CommitURL
+	case ReferenceURL:
+		return ref.ID
+	}
+	if template == "" {
+		return ""
+	}
+	return strings.NewReplacer("{base}", r.base, "{repo}", repo, "{id}", ref.ID).Replace(template)
+}
+
+// Linkify returns a copy of the version in which the references the
+// resolver knows the URL of link to it, e.g. "#123" becomes
+// "[#123](https://github.com/parkr/changelog/issues/123)". If markdown is
+// false, the references are replaced by their URL instead. The way the
+// references are listed is kept.
+func (v *Version) Linkify(resolver Resolver, markdown bool) *Version {
+	linkify := func(lines []*ChangeLine) []*ChangeLine {
+		linked := make([]*ChangeLine, len(lines))
+		for i, line := range lines {
+			copied := *line
+			copied.Reference = linkifyReferences(line.Reference, resolver, markdown)
+			if line.referenceSource != "" {
+				copied.referenceSource = linkifyReferences(line.referenceSource, resolver, markdown)
+			}
+			linked[i] = &copied
+		}
+		return linked
+	}
+
+	copied := *v
+	copied.History = linkify(v.History)
+	copied.Subsections = make([]*Subsection, len(v.Subsections))
+	for i, subsection := range v.Subsections {
+		copiedSubsection := *subsection
+		copiedSubsection.History = linkify(subsection.History)
+		copied.Subsections[i] = &copiedSubsection
+	}
+	return &copied
+}
+
+// linkifyReferences links each reference in the list to its URL.
+func linkifyReferences(list string, resolver Resolver, markdown bool) string {
+	return referenceTokenRegexp.ReplaceAllStringFunc(list, func(text string) string {
+		if _, url, ok := markdownLink(text); ok {
+			// The reference is linked already.
+			if markdown {
+				return text
+			}
+			return url
+		}
+		ref := parseReference(text)
+		url := resolver.URL(ref)
+		switch {
+		case url == "" || ref.Kind == ReferenceURL && markdown:
+			// Markdown renderers link bare URLs already.
+			return text
+		case markdown:
+			return "[" + text + "](" + url + ")"
+		}
+		return url
+	})
+}
@@ -0,0 +1,129 @@
+package changelog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForgeResolverURL(t *testing.T) {
+	refs := []Reference{
+		parseReference("#123"),
+		parseReference("!45"),
+		parseReference("foo/bar#9"),
+		parseReference("@parkr"),
+		parseReference("commit: 0f4477"),
+		parseReference("https://example.com/bug/12"),
+		parseReference("carla"),
+	}
+	testCases := []struct {
+		repoURL  string
+		expected []string
+	}{
+		{"https://github.com/parkr/changelog", []string{
+			"https://github.com/parkr/changelog/issues/123",
+			"https://github.com/parkr/changelog/pull/45",
+			"https://github.com/foo/bar/issues/9",
+			"https://github.com/parkr",
+			"https://github.com/parkr/changelog/commit/0f4477",
+			"https://example.com/bug/12",
+			"",
+		}},
+		{"https://gitlab.com/group/subgroup/project.git", []string{
+			"https://gitlab.com/group/subgroup/project/-/issues/123",
+			"https://gitlab.com/group/subgroup/project/-/merge_requests/45",
+			"https://gitlab.com/foo/bar/-/issues/9",
+			"https://gitlab.com/parkr",
+			"https://gitlab.com/group/subgroup/project/-/commit/0f4477",
+			"https://example.com/bug/12",
+			"",
+		}},
+		{"https://codeberg.org/parkr/changelog/", []string{
+			"https://codeberg.org/parkr/changelog/issues/123",
+			"https://codeberg.org/parkr/changelog/pulls/45",
+			"https://codeberg.org/foo/bar/issues/9",
+			"https://codeberg.org/parkr",
+			"https://codeberg.org/parkr/changelog/commit/0f4477",
+			"https://example.com/bug/12",
+			"",
+		}},
+		{"https://bitbucket.org/parkr/changelog", []string{
+			"https://bitbucket.org/parkr/changelog/issues/123",
+			"https://bitbucket.org/parkr/changelog/pull-requests/45",
+			"https://bitbucket.org/foo/bar/issues/9",
+			"",
+			"https://bitbucket.org/parkr/changelog/commits/0f4477",
+			"https://example.com/bug/12",
+			"",
+		}},
+	}
+	for _, testCase := range testCases {
+		resolver, err := NewResolver(testCase.repoURL)
+		assert.NoError(t, err)
+		for i, ref := range refs {
+			assert.Equal(t, testCase.expected[i], resolver.URL(ref), "%s in %s", ref.Text, testCase.repoURL)
+		}
+	}
+}
+
+func TestForgeResolver(t *testing.T) {
+	resolver, err := Gitea.Resolver("https://git.example.com/parkr/changelog")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://git.example.com/parkr/changelog/pulls/45", resolver.URL(parseReference("!45")))
+
+	_, err = GitHub.Resolver("parkr/changelog")
+	assert.EqualError(t, err, `"parkr/changelog" is not the URL of a repository, like https://github.com/owner/repo`)
+	_, err = NewResolver("https://github.com/")
+	assert.Error(t, err)
+}
+
+func TestForgeByName(t *testing.T) {
+	forge, err := ForgeByName("GitLab")
+	assert.NoError(t, err)
+	assert.Equal(t, GitLab, forge)
+
+	_, err = ForgeByName("sourceforge")
+	assert.EqualError(t, err, `unknown forge "sourceforge"`)
+}
+
+func TestVersionLinkify(t *testing.T) {
+	version := NewVersion("4.1.0")
+	version.History = []*ChangeLine{
+		{Summary: "Fix crash", Reference: "#123, #456, @alice, foo/bar#7"},
+		{Summary: "Add basic support for JRuby", Reference: "commit: 0f4477"},
+		{Summary: "Document it", Reference: "https://example.com/docs, carla"},
+	}
+	resolver, err := NewResolver("https://github.com/parkr/changelog")
+	assert.NoError(t, err)
+
+	linked := version.Linkify(resolver, true)
+	assert.Equal(t, "[#123](https://github.com/parkr/changelog/issues/123), [#456](https://github.com/parkr/changelog/issues/456), [@alice](https://github.com/alice), [foo/bar#7](https://github.com/foo/bar/issues/7)", linked.History[0].Reference)
+	assert.Equal(t, "[commit: 0f4477](https://github.com/parkr/changelog/commit/0f4477)", linked.History[1].Reference)
+	assert.Equal(t, "https://example.com/docs, carla", linked.History[2].Reference)
+
+	linked = version.Linkify(resolver, false)
+	assert.Equal(t, "https://github.com/parkr/changelog/commit/0f4477", linked.History[1].Reference)
+	assert.Equal(t, "commit: 0f4477", version.History[1].Reference, "the version should not change")
+}
+
+func TestVersionLinkify_Subsections(t *testing.T) {
+	version := notesVersion()
+	resolver, err := NewResolver("https://github.com/parkr/changelog/")
+	assert.NoError(t, err)
+
+	linked := version.Linkify(resolver, true)
+	assert.Equal(t, "[#100](https://github.com/parkr/changelog/issues/100)", linked.History[0].Reference)
+	assert.Equal(t, "[@parkr](https://github.com/parkr)", linked.Subsections[0].History[0].Reference)
+	assert.Equal(t, "@parkr", version.Subsections[0].History[0].Reference, "the version should not change")
+}
+
+func TestVersionLinkify_ReferenceGroups(t *testing.T) {
+	history := parseMarkdown(t, "## HEAD\n\n  * Fix crash (#123) (@alice)\n")
+	resolver, err := NewResolver("https://github.com/parkr/changelog")
+	assert.NoError(t, err)
+
+	linked := history.GetVersion("HEAD").Linkify(resolver, true)
+
+	assert.Equal(t, "[#123](https://github.com/parkr/changelog/issues/123), [@alice](https://github.com/alice)", linked.History[0].Reference)
+	assert.Equal(t, "  * Fix crash ([#123](https://github.com/parkr/changelog/issues/123)) ([@alice](https://github.com/alice))", linked.History[0].String())
+}
@@ -0,0 +1,219 @@
+package changelog
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// semver is a version number as described by https://semver.org. Missing
+// minor and patch numbers are treated as 0, so that "1.0" can be compared
+// with "1.0.1".
+type semver struct {
+	major, minor, patch int
+	prerelease          []string
+	build               string
+}
+
+// parseSemver parses a version number like "v1.2.3-rc.1+build.5". The
+// "v" prefix and the surrounding brackets Keep a Changelog uses are
+// optional.
+func parseSemver(versionNum string) (*semver, bool) {
+	str := strings.Trim(strings.TrimSpace(versionNum), "[]")
+	str = strings.TrimPrefix(strings.TrimPrefix(str, "v"), "V")
+
+	version := &semver{}
+	if i := strings.Index(str, "+"); i >= 0 {
+		str, version.build = str[:i], str[i+1:]
+		if version.build == "" {
+			return nil, false
+		}
+	}
+	if i := strings.Index(str, "-"); i >= 0 {
+		var prerelease string
+		str, prerelease = str[:i], str[i+1:]
+		version.prerelease = strings.Split(prerelease, ".")
+		for _, identifier := range version.prerelease {
+			if identifier == "" {
+				return nil, false
+			}
+		}
+	}
+
+	parts := strings.Split(str, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return nil, false
+	}
+	numbers := []*int{&version.major, &version.minor, &version.patch}
+	for i, part := range parts {
+		if !isNumeric(part) {
+			return nil, false
+		}
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, false
+		}
+		*numbers[i] = number
+	}
+	return version, true
+}
+
+// isNumeric checks whether the string is made of digits only.
+func isNumeric(str string) bool {
+	if str == "" {
+		return false
+	}
+	for _, r := range str {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// compareInts returns -1, 0 or +1 depending on whether a is less than,
+// equal to, or greater than b.
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compare returns -1, 0 or +1 depending on whether the version has lower,
+// equal or higher precedence than the other. Build metadata is ignored, as
+// the specification requires.
+func (v *semver) compare(other *semver) int {
+	if result := compareInts(v.major, other.major); result != 0 {
+		return result
+	}
+	if result := compareInts(v.minor, other.minor); result != 0 {
+		return result
+	}
+	if result := compareInts(v.patch, other.patch); result != 0 {
+		return result
+	}
+
+	// A pre-release has a lower precedence than the release itself.
+	switch {
+	case len(v.prerelease) == 0 && len(other.prerelease) == 0:
+		return 0
+	case len(v.prerelease) == 0:
+		return 1
+	case len(other.prerelease) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.prerelease) && i < len(other.prerelease); i++ {
+		a, b := v.prerelease[i], other.prerelease[i]
+		aNumeric, bNumeric := isNumeric(a), isNumeric(b)
+		switch {
+		case aNumeric && bNumeric:
+			aNum, _ := strconv.Atoi(a)
+			bNum, _ := strconv.Atoi(b)
+			if result := compareInts(aNum, bNum); result != 0 {
+				return result
+			}
+		case aNumeric:
+			return -1
+		case bNumeric:
+			return 1
+		default:
+			if result := strings.Compare(a, b); result != 0 {
+				return result
+			}
+		}
+	}
+	return compareInts(len(v.prerelease), len(other.prerelease))
+}
+
+// versionRank orders the kinds of versions: the changes without a version
+// header come first, then the unreleased changes, then releases.
+func versionRank(versionNum string) int {
+	switch {
+	case strings.TrimSpace(versionNum) == "":
+		return 2
+	case isUnreleased(versionNum):
+		return 1
+	}
+	return 0
+}
+
+// Compare returns -1, 0 or +1 depending on whether the version comes
+// before, is the same as, or comes after the other in the order of
+// semantic versioning, e.g. "1.0.0-beta" < "1.0.0" < "v1.0.1". The
+// unreleased changes, "HEAD" or "[Unreleased]," compare higher than any
+// release. Releases which aren't semantic versions compare lower than the
+// ones which are, and equal to each other.
+func (v *Version) Compare(other *Version) int {
+	if result := compareInts(versionRank(v.Version), versionRank(other.Version)); result != 0 {
+		return result
+	}
+	a, aOk := parseSemver(v.Version)
+	b, bOk := parseSemver(other.Version)
+	switch {
+	case aOk && bOk:
+		return a.compare(b)
+	case aOk:
+		return 1
+	case bOk:
+		return -1
+	}
+	return 0
+}
+
+// SortBySemver sorts the versions from the highest to the lowest semantic
+// version. The changes without a version header and the unreleased
+// changes stay at the top, and releases which aren't semantic versions
+// keep their order at the bottom.
+func (c *Changelog) SortBySemver() {
+	c.sortVersions()
+	sort.SliceStable(c.Versions, func(i, j int) bool {
+		return c.Versions[i].Compare(c.Versions[j]) > 0
+	})
+	sortOrder := 1
+	for _, v := range c.Versions {
+		if v.sortOrder > 0 {
+			v.sortOrder = sortOrder
+			sortOrder++
+		}
+	}
+}
+
+// insertVersion adds the version to the changelog. Releases are placed
+// before the first release with a lower semantic version, or at the end
+// if there is none.
+func (c *Changelog) insertVersion(version *Version) {
+	if version.sortOrder > 0 {
+		if _, ok := parseSemver(version.Version); ok {
+			for _, existing := range c.Versions {
+				if existing.sortOrder > 0 && version.Compare(existing) > 0 {
+					position := existing.sortOrder
+					for _, later := range c.Versions {
+						if later.sortOrder >= position {
+							later.sortOrder++
+						}
+					}
+					version.sortOrder = position
+					c.Versions = append(c.Versions, version)
+					c.sortVersions()
+					return
+				}
+			}
+		}
+	}
+	c.appendVersion(version)
+}
+
+// appendVersion adds the version to the end of the changelog.
+func (c *Changelog) appendVersion(version *Version) {
+	if len(c.Versions) > 0 && version.sortOrder > 0 && c.Versions[len(c.Versions)-1].sortOrder > 0 {
+		logVerbose("previous sortOrder:", c.Versions[len(c.Versions)-1].sortOrder, "version:", c.Versions[len(c.Versions)-1].Version)
+		version.sortOrder = c.Versions[len(c.Versions)-1].sortOrder + 1
+	}
+	logVerbose("sortOrder:", version.sortOrder, "version:", version.Version)
+	c.Versions = append(c.Versions, version)
+	c.sortVersions()
+}
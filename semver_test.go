@@ -0,0 +1,101 @@
+package changelog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSemver(t *testing.T) {
+	version, ok := parseSemver("v1.2.3-rc.1+build.5")
+	assert.True(t, ok)
+	assert.Equal(t, &semver{major: 1, minor: 2, patch: 3, prerelease: []string{"rc", "1"}, build: "build.5"}, version)
+
+	version, ok = parseSemver("[0.6]")
+	assert.True(t, ok)
+	assert.Equal(t, &semver{major: 0, minor: 6}, version)
+
+	for _, invalid := range []string{"", "HEAD", "[Unreleased]", "1", "1.2.3.4", "1.x.0", "1.0.0-", "1.0.0-rc..1", "1.0.0+"} {
+		_, ok := parseSemver(invalid)
+		assert.False(t, ok, "%q should not be a semantic version", invalid)
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	// Each version is lower than the one before it.
+	ordered := []string{
+		"",
+		"HEAD",
+		"2.0.0",
+		"v1.10.0",
+		"1.9.1",
+		"1.9",
+		"1.0.0",
+		"1.0.0-rc.1",
+		"1.0.0-beta.11",
+		"1.0.0-beta.2",
+		"1.0.0-beta",
+		"1.0.0-alpha.beta",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha",
+		"2015-02-20",
+	}
+	for i := 0; i < len(ordered)-1; i++ {
+		higher, lower := NewVersion(ordered[i]), NewVersion(ordered[i+1])
+		assert.Equal(t, 1, higher.Compare(lower), "%q should be higher than %q", higher.Version, lower.Version)
+		assert.Equal(t, -1, lower.Compare(higher), "%q should be lower than %q", lower.Version, higher.Version)
+	}
+
+	assert.Equal(t, 0, NewVersion("1.0.0+build.1").Compare(NewVersion("v1.0.0")))
+	assert.Equal(t, 0, NewVersion("HEAD").Compare(NewVersion("[Unreleased]")))
+	assert.Equal(t, 0, NewVersion("foo").Compare(NewVersion("bar")))
+}
+
+func TestGetVersionOrCreate_SemverOrder(t *testing.T) {
+	history, err := NewChangelogFromFile("testdata/History.markdown")
+	assert.NoError(t, err)
+	assert.Equal(t, "HEAD", history.Versions[0].Version)
+	assert.Equal(t, "2.5.3", history.Versions[1].Version)
+
+	history.GetVersionOrCreate("3.0.0")
+	history.GetVersionOrCreate("2.4.1")
+
+	assert.Equal(t, "HEAD", history.Versions[0].Version)
+	assert.Equal(t, "3.0.0", history.Versions[1].Version)
+	assert.Equal(t, "2.5.3", history.Versions[2].Version)
+	position := versionIndex(history, "2.4.1")
+	assert.Equal(t, "2.5.0", history.Versions[position-1].Version)
+	assert.Equal(t, "2.4.0", history.Versions[position+1].Version)
+	assert.True(t, strings.Index(history.String(), "## 2.5.0 /") < strings.Index(history.String(), "## 2.4.1\n"))
+}
+
+func TestParseChangelog_KeepsVersionOrder(t *testing.T) {
+	history, err := NewChangelogFromReader(strings.NewReader("## 1.0.0\n\n## 2.0.0\n"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "## 1.0.0\n\n## 2.0.0\n", history.String())
+}
+
+func TestSortBySemver(t *testing.T) {
+	history, err := NewChangelogFromReader(strings.NewReader("## 1.0.0\n\n## HEAD\n\n## v2.0.0-beta\n\n## 2.0.0\n"))
+	assert.NoError(t, err)
+	history.getVersionOrAppend("unnamed-release")
+	history.getVersionOrAppend("0.1.0")
+
+	history.SortBySemver()
+
+	assert.Equal(t, "## HEAD\n\n## 2.0.0\n\n## v2.0.0-beta\n\n## 1.0.0\n\n## 0.1.0\n\n## unnamed-release\n", history.String())
+	assertSortOrder(t, history, "HEAD", 0)
+	assertSortOrder(t, history, "2.0.0", 1)
+	assertSortOrder(t, history, "unnamed-release", 5)
+}
+
+func versionIndex(history *Changelog, versionNum string) int {
+	for i, v := range history.Versions {
+		if v.Version == versionNum {
+			return i
+		}
+	}
+	return -1
+}
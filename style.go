@@ -0,0 +1,95 @@
+package changelog
+
+import (
+	"strings"
+)
+
+// Style holds the formatting details of a changelog which a Dialect leaves
+// open, like the list marker used for changes. The parser detects the
+// style of the changelog it reads so that it is written back the same way.
+type Style struct {
+	// Bullet is the list marker used for changes, "*" or "-".
+	Bullet string
+	// Indent is the number of spaces before the bullet.
+	Indent int
+	// IndentWith is what the indent is made of, " " or "\t". If empty,
+	// spaces are used.
+	IndentWith string
+	// HeaderDepth is the number of "#" in version headers, 1 or 2.
+	// Subsection headers always use "###".
+	HeaderDepth int
+	// DateSeparator goes between the version and its date, e.g. " / " or
+	// " - ".
+	DateSeparator string
+	// LineEnding ends every line, "\n" or "\r\n". If empty, "\n" is used.
+	LineEnding string
+}
+
+// bullet returns the indented list marker, followed by a space.
+func (s *Style) bullet() string {
+	indent := s.IndentWith
+	if indent == "" {
+		indent = " "
+	}
+	return strings.Repeat(indent, s.Indent) + s.Bullet + " "
+}
+
+// header returns the prefix of a version header, e.g. "## ".
+func (s *Style) header() string {
+	return strings.Repeat("#", s.HeaderDepth) + " "
+}
+
+// withDefaults returns a copy of the style with any fields which aren't
+// set taken from the given default style. Indent is always used as-is.
+func (s Style) withDefaults(defaults Style) Style {
+	if s.Bullet == "" {
+		s.Bullet = defaults.Bullet
+	}
+	if s.HeaderDepth == 0 {
+		s.HeaderDepth = defaults.HeaderDepth
+	}
+	if s.DateSeparator == "" {
+		s.DateSeparator = defaults.DateSeparator
+	}
+	if s.LineEnding == "" {
+		s.LineEnding = defaults.LineEnding
+	}
+	return s
+}
+
+// detectChangeLineStyle fills in the Bullet and Indent of the style from
+// a change line, unless they have been detected already. An indent made
+// only of tabs is kept as tabs; any other indent is written as spaces.
+func (s *Style) detectChangeLineStyle(txt string) {
+	if s.Bullet != "" {
+		return
+	}
+	trimmed := strings.TrimLeft(txt, " \t")
+	s.Bullet = trimmed[:1]
+	s.Indent = len(txt) - len(trimmed)
+	if s.Indent > 0 && strings.Trim(txt[:s.Indent], "\t") == "" {
+		s.IndentWith = "\t"
+	}
+}
+
+// detectHeaderStyle fills in the HeaderDepth and DateSeparator of the
+// style from a version header, unless they have been detected already.
+func (s *Style) detectHeaderStyle(header, versionNum, date string) {
+	trimmed := strings.TrimSpace(header)
+	if s.HeaderDepth == 0 {
+		s.HeaderDepth = len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+	}
+	if s.DateSeparator != "" || date == "" {
+		return
+	}
+	afterVersion := strings.Index(trimmed, versionNum) + len(versionNum)
+	dateStart := strings.LastIndex(trimmed, date)
+	if afterVersion > dateStart {
+		return
+	}
+	separator := strings.TrimPrefix(trimmed[afterVersion:dateStart], "]")
+	switch separator {
+	case " / ", " - ":
+		s.DateSeparator = separator
+	}
+}
@@ -0,0 +1,83 @@
+package changelog
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseChangelog_DetectsStyle(t *testing.T) {
+	for _, testCase := range []struct {
+		input    string
+		expected Style
+	}{
+		{
+			input:    "## 1.0.0 / 2015-02-20\n\n  * Initial release\n",
+			expected: Style{Bullet: "*", Indent: 2, HeaderDepth: 2, DateSeparator: " / "},
+		},
+		{
+			input:    "# 1.0.0 - 2015-02-20\n\n- Initial release\n",
+			expected: Style{Bullet: "-", Indent: 0, HeaderDepth: 1, DateSeparator: " - "},
+		},
+		{
+			input:    "## [1.0.0] / 2015-02-20\n\n    * Initial release\n",
+			expected: Style{Bullet: "*", Indent: 4, HeaderDepth: 2, DateSeparator: " / "},
+		},
+		{
+			input:    "## 1.0.0 / 2015-02-20\n\n\t* Initial release\n",
+			expected: Style{Bullet: "*", Indent: 1, IndentWith: "\t", HeaderDepth: 2, DateSeparator: " / "},
+		},
+		{
+			// Nothing to detect, so the dialect's default style is used.
+			input:    "## [1.0.0]\n",
+			expected: KeepAChangelog.DefaultStyle(),
+		},
+		{
+			input:    "## 1.0.0 (2015-02-20)\n\n- Initial release\n",
+			expected: Style{Bullet: "-", Indent: 0, HeaderDepth: 2, DateSeparator: " / "},
+		},
+	} {
+		history, err := NewChangelogFromReader(strings.NewReader(testCase.input))
+		assert.NoError(t, err)
+		assert.Equal(t, &testCase.expected, history.Style, "wrong style for %q", testCase.input)
+	}
+}
+
+func TestChangelog_WritesWhatItParses_OwnHistory(t *testing.T) {
+	expected, err := os.ReadFile("History.markdown")
+	assert.NoError(t, err)
+
+	history, err := NewChangelogFromReader(strings.NewReader(string(expected)))
+	assert.NoError(t, err)
+
+	assert.Equal(t, string(expected), history.String())
+}
+
+func TestChangelog_WritesWhatItParses_TabIndent(t *testing.T) {
+	source := "## HEAD\n\n\t* Fix the tokenizer (#2)\n\n## 1.0.0 / 2015-02-20\n\n\t* Initial release (#1)\n"
+	history, err := NewChangelogFromReader(strings.NewReader(source))
+	assert.NoError(t, err)
+
+	history.AddLineToVersion("HEAD", &ChangeLine{Summary: "Fix the parser", Reference: "#3"})
+
+	assert.Equal(t, strings.Replace(source, "(#2)\n", "(#2)\n\t* Fix the parser (#3)\n", 1), history.String())
+}
+
+func TestChangelogString_StyleOverride(t *testing.T) {
+	history := NewChangelog()
+	history.GetVersionOrCreate("1.0.0").Date = "2015-02-20"
+	history.AddLineToVersion("1.0.0", &ChangeLine{Summary: "Initial release", Reference: "#1"})
+
+	history.Style = &Style{Bullet: "-", Indent: 0, HeaderDepth: 1}
+
+	assert.Equal(t, "# 1.0.0 / 2015-02-20\n\n- Initial release (#1)\n", history.String())
+}
+
+func TestChangelogString_NilStyle(t *testing.T) {
+	history := &Changelog{Dialect: KeepAChangelog}
+	history.AddLineToVersion("1.0.0", &ChangeLine{Summary: "Initial release"})
+
+	assert.Equal(t, "## [1.0.0]\n\n- Initial release\n", history.String())
+}